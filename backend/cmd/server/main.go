@@ -20,13 +20,16 @@ import (
 )
 
 type Server struct {
-	cfg          *config.Config
-	db           *db.DynamoDB
-	storage      *storage.S3Storage
-	cache        *cache.LRUCache
-	translator   *translate.OpenAITranslator
-	pasteHandler *handlers.PasteHandler
-	router       *mux.Router
+	cfg             *config.Config
+	db              *db.DynamoDB
+	storage         *storage.S3Storage
+	cache           *cache.LRUCache
+	translator      translate.Translator
+	pasteHandler    *handlers.PasteHandler
+	glossaryHandler *handlers.GlossaryHandler
+	toneHandler     *handlers.ToneHandler
+	adminHandler    *handlers.AdminHandler
+	router          *mux.Router
 }
 
 func main() {
@@ -43,6 +46,8 @@ func main() {
 		cfg.DynamoDBAccountsTable,
 		cfg.DynamoDBPastesTable,
 		cfg.DynamoDBRateLimitsTable,
+		cfg.DynamoDBUsageTable,
+		cfg.DynamoDBMemoryTable,
 	)
 	if err != nil {
 		log.Fatalf("Failed to initialize DynamoDB: %v", err)
@@ -54,28 +59,51 @@ func main() {
 	}
 
 	lruCache := cache.NewLRUCache(cfg.CacheSize)
-	translator := translate.NewOpenAITranslator(cfg.OpenAIAPIKey, cfg.OpenAIModel)
-	pasteHandler := handlers.NewPasteHandler(dynamoDB, s3Storage, lruCache, translator, cfg.MaxPasteLength)
+	provider, err := newRoutingTranslator(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize translator: %v", err)
+	}
+	translator := translate.NewFormatTranslator(
+		translate.NewMemoryTranslator(
+			translate.NewCodeAwareTranslator(
+				translate.NewPlaceholderTranslator(
+					translate.NewDetectingTranslator(
+						translate.NewChunkedTranslator(provider, cfg.TranslateChunkTokens, cfg.TranslateConcurrency),
+						cfg.DetectMinConfidence,
+					),
+				),
+			),
+			dynamoDB,
+			lruCache,
+		),
+	)
+	pasteHandler := handlers.NewPasteHandler(dynamoDB, s3Storage, lruCache, translator, cfg.MaxPasteLength, cfg.MaxPaidPasteLength, cfg.TranslateBatchConcurrency)
 
 	server := &Server{
-		cfg:          cfg,
-		db:           dynamoDB,
-		storage:      s3Storage,
-		cache:        lruCache,
-		translator:   translator,
-		pasteHandler: pasteHandler,
-		router:       mux.NewRouter(),
+		cfg:             cfg,
+		db:              dynamoDB,
+		storage:         s3Storage,
+		cache:           lruCache,
+		translator:      translator,
+		pasteHandler:    pasteHandler,
+		glossaryHandler: handlers.NewGlossaryHandler(dynamoDB, lruCache),
+		toneHandler:     handlers.NewToneHandler(dynamoDB, lruCache),
+		adminHandler:    handlers.NewAdminHandler(dynamoDB, cfg.AdminAccountIDs),
+		router:          mux.NewRouter(),
 	}
 
 	server.setupRoutes()
 
 	corsMiddleware := middleware.NewCORS(cfg.FrontendURL)
+	authenticator := middleware.NewAuthenticator(cfg.JWTSecret)
 	rateLimiter := middleware.NewRateLimiter(dynamoDB)
 
 	handler := corsMiddleware.Handler(
 		middleware.Logger(
 			middleware.ExtractIP(
-				rateLimiter.Middleware(server.router),
+				authenticator.Middleware(
+					rateLimiter.Middleware(server.router),
+				),
 			),
 		),
 	)
@@ -110,13 +138,98 @@ func main() {
 	log.Println("Server exited")
 }
 
+// newRoutingTranslator builds every configured backend, each with its own
+// retries, circuit breaker and output checks, behind a router applying the
+// configured routes and fallback order.
+func newRoutingTranslator(cfg *config.Config) (*translate.RoutingTranslator, error) {
+	backends := make([]translate.Backend, 0, len(cfg.TranslatorBackends))
+	for _, backend := range cfg.TranslatorBackends {
+		var translator translate.Translator = translate.NewResilientTranslator(
+			newTranslator(cfg, backend),
+			translate.NewCircuitBreaker(backend.Name, cfg.BreakerFailureThreshold, cfg.BreakerCooldown),
+			cfg.TranslateMaxAttempts,
+			cfg.TranslateRetryBaseDelay,
+			cfg.TranslateRetryMaxDelay,
+			cfg.TranslateCallTimeout,
+		)
+		// The fake translator echoes its input, which the guard would
+		// rightly reject.
+		if backend.Provider != config.ProviderFake {
+			translator = translate.NewGuardedTranslator(translator)
+		}
+		backends = append(backends, translate.Backend{Name: backend.Name, Translator: translator})
+	}
+
+	routes := make([]translate.Route, 0, len(cfg.TranslatorRoutes))
+	for _, route := range cfg.TranslatorRoutes {
+		routes = append(routes, translate.Route{
+			Source:   route.Source,
+			Target:   route.Target,
+			Tone:     route.Tone,
+			Backends: route.Backends,
+		})
+	}
+
+	return translate.NewRoutingTranslator(backends, routes)
+}
+
+func newTranslator(cfg *config.Config, backend config.TranslatorBackend) translate.Translator {
+	policy := modelPolicy(cfg.ModelRules, backend.Name)
+	switch backend.Provider {
+	case config.ProviderOpenAICompatible:
+		return translate.NewOpenAICompatibleTranslator(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, backend.Model, policy)
+	case config.ProviderFake:
+		log.Printf("Backend %s uses the fake translator; translations are not real", backend.Name)
+		return translate.NewFakeTranslator()
+	default:
+		return translate.NewOpenAITranslator(cfg.OpenAIAPIKey, backend.Model, policy)
+	}
+}
+
+// modelPolicy collects the rules for a backend, letting rules that name it
+// override general ones.
+func modelPolicy(rules []config.ModelRule, backend string) translate.ModelPolicy {
+	policy := make(translate.ModelPolicy)
+	for _, rule := range rules {
+		if rule.Backend == "" {
+			policy[translate.ModelKey{Tier: rule.Tier, Operation: rule.Operation}] = rule.Model
+		}
+	}
+	for _, rule := range rules {
+		if rule.Backend == backend {
+			policy[translate.ModelKey{Tier: rule.Tier, Operation: rule.Operation}] = rule.Model
+		}
+	}
+	return policy
+}
+
 func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/health", s.handleHealth).Methods("GET")
 
 	api := s.router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/languages", handlers.ListLanguages).Methods("GET")
 	api.HandleFunc("/pastes", s.pasteHandler.Create).Methods("POST")
 	api.HandleFunc("/pastes/{id}", s.pasteHandler.Get).Methods("GET")
+	api.HandleFunc("/pastes/{id}/translate", s.pasteHandler.TranslateBatch).Methods("GET").Queries("langs", "{langs}")
+	api.HandleFunc("/pastes/{id}/translate", s.pasteHandler.TranslateBatch).Methods("POST")
 	api.HandleFunc("/pastes/{id}/translate", s.pasteHandler.Translate).Methods("GET")
+	api.HandleFunc("/pastes/{id}/translate/stream", s.pasteHandler.TranslateStream).Methods("GET")
+	api.HandleFunc("/pastes/{id}/align", s.pasteHandler.Align).Methods("GET")
+	api.HandleFunc("/pastes/{id}/raw", s.pasteHandler.Raw).Methods("GET")
+
+	api.HandleFunc("/glossary", s.glossaryHandler.Get).Methods("GET")
+	api.HandleFunc("/glossary", s.glossaryHandler.Replace).Methods("PUT")
+	api.HandleFunc("/glossary/terms/{term}", s.glossaryHandler.PutTerm).Methods("PUT")
+	api.HandleFunc("/glossary/terms/{term}", s.glossaryHandler.DeleteTerm).Methods("DELETE")
+
+	api.HandleFunc("/tones", s.toneHandler.List).Methods("GET")
+	api.HandleFunc("/tones/{name}", s.toneHandler.Put).Methods("PUT")
+	api.HandleFunc("/tones/{name}", s.toneHandler.Delete).Methods("DELETE")
+
+	api.HandleFunc("/account/retranslate", s.pasteHandler.Retranslate).Methods("POST")
+
+	api.HandleFunc("/admin/spend", s.adminHandler.Spend).Methods("GET")
+	api.HandleFunc("/admin/vars", s.adminHandler.Vars).Methods("GET")
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
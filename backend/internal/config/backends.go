@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TranslatorBackend is a named provider and model. Every backend shares
+// OPENAI_API_KEY and OPENAI_BASE_URL.
+type TranslatorBackend struct {
+	Name     string
+	Provider string
+	Model    string
+}
+
+// TranslatorRoute prefers Backends, in order, for translations matching
+// Source, Target and Tone. "*" or an empty field matches anything; a base
+// language such as "es" also matches its regional tags.
+type TranslatorRoute struct {
+	Source   string
+	Target   string
+	Tone     string
+	Backends []string
+}
+
+// ModelRule uses Model for calls made on behalf of accounts of Tier
+// ("free" or "paid") for Operation ("detect" or "translate"). "*" matches
+// any tier or operation; an empty Backend applies the rule to every
+// backend.
+type ModelRule struct {
+	Backend   string
+	Tier      string
+	Operation string
+	Model     string
+}
+
+// parseBackends parses TRANSLATOR_BACKENDS, a comma-separated list of
+// name=provider:model entries, e.g.
+//
+//	primary=openai:gpt-4o-mini,large=openai:gpt-4o
+//
+// Without it, a single backend named after TRANSLATOR_PROVIDER uses
+// OPENAI_MODEL.
+func parseBackends(value, provider, model string) ([]TranslatorBackend, error) {
+	if strings.TrimSpace(value) == "" {
+		return []TranslatorBackend{{Name: provider, Provider: provider, Model: model}}, nil
+	}
+
+	var backends []TranslatorBackend
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid TRANSLATOR_BACKENDS entry %q, expected name=provider:model", entry)
+		}
+		backendProvider, backendModel, _ := strings.Cut(spec, ":")
+		if backendModel == "" && backendProvider != ProviderFake {
+			return nil, fmt.Errorf("TRANSLATOR_BACKENDS entry %q has no model", entry)
+		}
+		backends = append(backends, TranslatorBackend{Name: name, Provider: backendProvider, Model: backendModel})
+	}
+	return backends, nil
+}
+
+// parseRoutes parses TRANSLATOR_ROUTES, a semicolon-separated list of
+// source>target[~tone]=backend,... rules, e.g.
+//
+//	ja>en=large,primary;*>ko=large;*>*~brusque=local
+//
+// The first matching rule wins.
+func parseRoutes(value string) ([]TranslatorRoute, error) {
+	var routes []TranslatorRoute
+	for _, rule := range strings.Split(value, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		match, list, ok := strings.Cut(rule, "=")
+		if !ok || list == "" {
+			return nil, fmt.Errorf("invalid TRANSLATOR_ROUTES rule %q, expected source>target[~tone]=backend,...", rule)
+		}
+		match, tone, _ := strings.Cut(match, "~")
+		source, target, ok := strings.Cut(match, ">")
+		if !ok {
+			return nil, fmt.Errorf("invalid TRANSLATOR_ROUTES rule %q, expected source>target[~tone]=backend,...", rule)
+		}
+
+		route := TranslatorRoute{
+			Source: strings.TrimSpace(source),
+			Target: strings.TrimSpace(target),
+			Tone:   strings.TrimSpace(tone),
+		}
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				route.Backends = append(route.Backends, name)
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// parseModelRules parses MODEL_POLICY, a comma-separated list of
+// [backend:]tier/operation=model rules, e.g.
+//
+//	free/translate=gpt-4o-mini,paid/translate=gpt-4o,*/detect=gpt-4.1-nano
+//
+// A rule naming a backend replaces a general rule for the same tier and
+// operation.
+func parseModelRules(value string) ([]ModelRule, error) {
+	var rules []ModelRule
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		match, model, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(model) == "" {
+			return nil, fmt.Errorf("invalid MODEL_POLICY rule %q, expected [backend:]tier/operation=model", entry)
+		}
+		backend, match, found := strings.Cut(match, ":")
+		if !found {
+			backend, match = "", backend
+		}
+		tier, operation, ok := strings.Cut(match, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid MODEL_POLICY rule %q, expected [backend:]tier/operation=model", entry)
+		}
+
+		rule := ModelRule{
+			Backend:   strings.TrimSpace(backend),
+			Tier:      strings.TrimSpace(tier),
+			Operation: strings.TrimSpace(operation),
+			Model:     strings.TrimSpace(model),
+		}
+		switch rule.Tier {
+		case "free", "paid", "*":
+		default:
+			return nil, fmt.Errorf("MODEL_POLICY rule %q has unknown tier %q", entry, rule.Tier)
+		}
+		switch rule.Operation {
+		case "detect", "translate", "*":
+		default:
+			return nil, fmt.Errorf("MODEL_POLICY rule %q has unknown operation %q", entry, rule.Operation)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (c *Config) validateBackends() error {
+	names := make(map[string]bool, len(c.TranslatorBackends))
+	for _, backend := range c.TranslatorBackends {
+		if names[backend.Name] {
+			return fmt.Errorf("duplicate translator backend %q", backend.Name)
+		}
+		names[backend.Name] = true
+
+		switch backend.Provider {
+		case ProviderOpenAI:
+			if c.OpenAIAPIKey == "" {
+				return fmt.Errorf("OPENAI_API_KEY is required")
+			}
+		case ProviderOpenAICompatible:
+			if c.OpenAIBaseURL == "" {
+				return fmt.Errorf("OPENAI_BASE_URL is required for the %s provider", ProviderOpenAICompatible)
+			}
+		case ProviderFake:
+		default:
+			return fmt.Errorf("unknown provider %q for translator backend %q", backend.Provider, backend.Name)
+		}
+	}
+
+	for _, route := range c.TranslatorRoutes {
+		for _, name := range route.Backends {
+			if !names[name] {
+				return fmt.Errorf("TRANSLATOR_ROUTES refers to unknown backend %q", name)
+			}
+		}
+	}
+
+	for _, rule := range c.ModelRules {
+		if rule.Backend != "" && !names[rule.Backend] {
+			return fmt.Errorf("MODEL_POLICY refers to unknown backend %q", rule.Backend)
+		}
+	}
+	return nil
+}
@@ -4,10 +4,19 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Translation providers selectable with TRANSLATOR_PROVIDER.
+const (
+	ProviderOpenAI           = "openai"
+	ProviderOpenAICompatible = "openai-compatible"
+	ProviderFake             = "fake"
+)
+
 type Config struct {
 	// AWS
 	AWSRegion               string
@@ -17,10 +26,47 @@ type Config struct {
 	DynamoDBAccountsTable   string
 	DynamoDBPastesTable     string
 	DynamoDBRateLimitsTable string
+	DynamoDBUsageTable      string
+	DynamoDBMemoryTable     string
+
+	// Translation
+	TranslatorProvider string
+	OpenAIAPIKey       string
+	OpenAIModel        string
+	OpenAIBaseURL      string
+
+	// TranslatorBackends are tried in order until one succeeds, after any
+	// preferred by the first matching TranslatorRoute.
+	TranslatorBackends []TranslatorBackend
+	TranslatorRoutes   []TranslatorRoute
+
+	// ModelRules override a backend's model by account tier and
+	// operation.
+	ModelRules []ModelRule
+
+	// Long pastes are split into segments of at most TranslateChunkTokens
+	// and translated with up to TranslateConcurrency parallel requests.
+	TranslateChunkTokens int
+	TranslateConcurrency int
+
+	// TranslateBatchConcurrency bounds how many languages of a batch
+	// translate request are translated at once.
+	TranslateBatchConcurrency int
+
+	// Language detection only falls back to the provider when the local
+	// detector's confidence is below DetectMinConfidence.
+	DetectMinConfidence float64
 
-	// OpenAI
-	OpenAIAPIKey string
-	OpenAIModel  string
+	// Transient provider failures are retried up to TranslateMaxAttempts
+	// times with exponential backoff; each call is bounded by
+	// TranslateCallTimeout. After BreakerFailureThreshold consecutive
+	// failures the provider is not called for BreakerCooldown.
+	TranslateMaxAttempts    int
+	TranslateCallTimeout    time.Duration
+	TranslateRetryBaseDelay time.Duration
+	TranslateRetryMaxDelay  time.Duration
+	BreakerFailureThreshold int
+	BreakerCooldown         time.Duration
 
 	// Auth
 	JWTSecret          string
@@ -29,6 +75,8 @@ type Config struct {
 	AppleClientID      string
 	AppleClientSecret  string
 	FrontendURL        string
+	// AdminAccountIDs may read spend reports.
+	AdminAccountIDs []string
 
 	// Stripe
 	StripeSecretKey     string
@@ -36,9 +84,10 @@ type Config struct {
 	StripePriceID       string
 
 	// Server
-	Port           string
-	CacheSize      int
-	MaxPasteLength int
+	Port               string
+	CacheSize          int
+	MaxPasteLength     int
+	MaxPaidPasteLength int
 }
 
 func Load() (*Config, error) {
@@ -46,27 +95,57 @@ func Load() (*Config, error) {
 	_ = godotenv.Load()
 
 	cfg := &Config{
-		AWSRegion:               getEnv("AWS_REGION", "ap-northeast-1"),
-		AWSAccessKeyID:          getEnv("AWS_ACCESS_KEY_ID", ""),
-		AWSSecretAccessKey:      getEnv("AWS_SECRET_ACCESS_KEY", ""),
-		S3BucketName:            getEnv("S3_BUCKET_NAME", "lingopaste-data"),
-		DynamoDBAccountsTable:   getEnv("DYNAMODB_ACCOUNTS_TABLE", "lingopaste-accounts"),
-		DynamoDBPastesTable:     getEnv("DYNAMODB_PASTES_TABLE", "lingopaste-pastes"),
-		DynamoDBRateLimitsTable: getEnv("DYNAMODB_RATE_LIMITS_TABLE", "lingopaste-rate-limits"),
-		OpenAIAPIKey:            getEnv("OPENAI_API_KEY", ""),
-		OpenAIModel:             getEnv("OPENAI_MODEL", "gpt-4o-mini"),
-		JWTSecret:               getEnv("JWT_SECRET", ""),
-		GoogleClientID:          getEnv("GOOGLE_CLIENT_ID", ""),
-		GoogleClientSecret:      getEnv("GOOGLE_CLIENT_SECRET", ""),
-		AppleClientID:           getEnv("APPLE_CLIENT_ID", ""),
-		AppleClientSecret:       getEnv("APPLE_CLIENT_SECRET", ""),
-		FrontendURL:             getEnv("FRONTEND_URL", "http://localhost:5173"),
-		StripeSecretKey:         getEnv("STRIPE_SECRET_KEY", ""),
-		StripeWebhookSecret:     getEnv("STRIPE_WEBHOOK_SECRET", ""),
-		StripePriceID:           getEnv("STRIPE_PRICE_ID", ""),
-		Port:                    getEnv("PORT", "8080"),
-		CacheSize:               getEnvInt("CACHE_SIZE", 100000),
-		MaxPasteLength:          getEnvInt("MAX_PASTE_LENGTH", 20000),
+		AWSRegion:                 getEnv("AWS_REGION", "ap-northeast-1"),
+		AWSAccessKeyID:            getEnv("AWS_ACCESS_KEY_ID", ""),
+		AWSSecretAccessKey:        getEnv("AWS_SECRET_ACCESS_KEY", ""),
+		S3BucketName:              getEnv("S3_BUCKET_NAME", "lingopaste-data"),
+		DynamoDBAccountsTable:     getEnv("DYNAMODB_ACCOUNTS_TABLE", "lingopaste-accounts"),
+		DynamoDBPastesTable:       getEnv("DYNAMODB_PASTES_TABLE", "lingopaste-pastes"),
+		DynamoDBRateLimitsTable:   getEnv("DYNAMODB_RATE_LIMITS_TABLE", "lingopaste-rate-limits"),
+		DynamoDBUsageTable:        getEnv("DYNAMODB_USAGE_TABLE", "lingopaste-usage"),
+		DynamoDBMemoryTable:       getEnv("DYNAMODB_MEMORY_TABLE", "lingopaste-translation-memory"),
+		TranslatorProvider:        getEnv("TRANSLATOR_PROVIDER", ProviderOpenAI),
+		OpenAIAPIKey:              getEnv("OPENAI_API_KEY", ""),
+		OpenAIModel:               getEnv("OPENAI_MODEL", "gpt-4o-mini"),
+		OpenAIBaseURL:             getEnv("OPENAI_BASE_URL", ""),
+		TranslateChunkTokens:      getEnvInt("TRANSLATE_CHUNK_TOKENS", 1500),
+		TranslateConcurrency:      getEnvInt("TRANSLATE_CONCURRENCY", 4),
+		TranslateBatchConcurrency: getEnvInt("TRANSLATE_BATCH_CONCURRENCY", 4),
+		DetectMinConfidence:       getEnvFloat("DETECT_MIN_CONFIDENCE", 0.6),
+		TranslateMaxAttempts:      getEnvInt("TRANSLATE_MAX_ATTEMPTS", 3),
+		TranslateCallTimeout:      getEnvDuration("TRANSLATE_CALL_TIMEOUT", 60*time.Second),
+		TranslateRetryBaseDelay:   getEnvDuration("TRANSLATE_RETRY_BASE_DELAY", 500*time.Millisecond),
+		TranslateRetryMaxDelay:    getEnvDuration("TRANSLATE_RETRY_MAX_DELAY", 10*time.Second),
+		BreakerFailureThreshold:   getEnvInt("BREAKER_FAILURE_THRESHOLD", 5),
+		BreakerCooldown:           getEnvDuration("BREAKER_COOLDOWN", 30*time.Second),
+		JWTSecret:                 getEnv("JWT_SECRET", ""),
+		GoogleClientID:            getEnv("GOOGLE_CLIENT_ID", ""),
+		GoogleClientSecret:        getEnv("GOOGLE_CLIENT_SECRET", ""),
+		AppleClientID:             getEnv("APPLE_CLIENT_ID", ""),
+		AppleClientSecret:         getEnv("APPLE_CLIENT_SECRET", ""),
+		AdminAccountIDs:           getEnvList("ADMIN_ACCOUNT_IDS"),
+		FrontendURL:               getEnv("FRONTEND_URL", "http://localhost:5173"),
+		StripeSecretKey:           getEnv("STRIPE_SECRET_KEY", ""),
+		StripeWebhookSecret:       getEnv("STRIPE_WEBHOOK_SECRET", ""),
+		StripePriceID:             getEnv("STRIPE_PRICE_ID", ""),
+		Port:                      getEnv("PORT", "8080"),
+		CacheSize:                 getEnvInt("CACHE_SIZE", 100000),
+		MaxPasteLength:            getEnvInt("MAX_PASTE_LENGTH", 20000),
+		MaxPaidPasteLength:        getEnvInt("MAX_PAID_PASTE_LENGTH", 100000),
+	}
+
+	var err error
+	cfg.TranslatorBackends, err = parseBackends(os.Getenv("TRANSLATOR_BACKENDS"), cfg.TranslatorProvider, cfg.OpenAIModel)
+	if err != nil {
+		return nil, err
+	}
+	cfg.TranslatorRoutes, err = parseRoutes(os.Getenv("TRANSLATOR_ROUTES"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.ModelRules, err = parseModelRules(os.Getenv("MODEL_POLICY"))
+	if err != nil {
+		return nil, err
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -77,8 +156,8 @@ func Load() (*Config, error) {
 }
 
 func (c *Config) Validate() error {
-	if c.OpenAIAPIKey == "" {
-		return fmt.Errorf("OPENAI_API_KEY is required")
+	if err := c.validateBackends(); err != nil {
+		return err
 	}
 	if c.JWTSecret == "" || len(c.JWTSecret) < 32 {
 		return fmt.Errorf("JWT_SECRET must be at least 32 characters")
@@ -104,3 +183,31 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if durationVal, err := time.ParseDuration(value); err == nil {
+			return durationVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -2,7 +2,9 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -100,3 +102,98 @@ func (db *DynamoDB) UpdateAccount(ctx context.Context, account *models.Account)
 
 	return nil
 }
+
+func (db *DynamoDB) SaveCustomTones(ctx context.Context, account *models.Account, tones []models.CustomTone) error {
+	now := time.Now().Unix()
+
+	value, err := attributevalue.Marshal(tones)
+	if err != nil {
+		return fmt.Errorf("failed to marshal custom tones: %w", err)
+	}
+
+	_, err = db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(db.AccountsTable),
+		Key: map[string]types.AttributeValue{
+			"email": &types.AttributeValueMemberS{Value: account.Email},
+		},
+		UpdateExpression: aws.String("SET custom_tones = :tones, updated_at = :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":tones": value,
+			":now":   &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save custom tones: %w", err)
+	}
+
+	account.CustomTones = tones
+	account.UpdatedAt = now
+	return nil
+}
+
+// ErrRetranslationRunning is returned when an account already has a
+// re-translation of its pastes in progress.
+var ErrRetranslationRunning = errors.New("re-translation already running")
+
+// ClaimRetranslation marks a re-translation of the account's pastes as
+// running until the given time, unless one already is. The mark is a lease
+// so that a re-translation whose server died does not block the account
+// for good.
+func (db *DynamoDB) ClaimRetranslation(ctx context.Context, account *models.Account, until time.Time) error {
+	_, err := db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(db.AccountsTable),
+		Key: map[string]types.AttributeValue{
+			"email": &types.AttributeValueMemberS{Value: account.Email},
+		},
+		UpdateExpression:    aws.String("SET retranslating_until = :until"),
+		ConditionExpression: aws.String("attribute_not_exists(retranslating_until) OR retranslating_until < :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":until": &types.AttributeValueMemberN{Value: strconv.FormatInt(until.Unix(), 10)},
+			":now":   &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)},
+		},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return ErrRetranslationRunning
+		}
+		return fmt.Errorf("failed to claim re-translation: %w", err)
+	}
+	account.RetranslatingUntil = until.Unix()
+	return nil
+}
+
+// RenewRetranslation extends the lease taken by ClaimRetranslation.
+func (db *DynamoDB) RenewRetranslation(ctx context.Context, account *models.Account, until time.Time) error {
+	_, err := db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(db.AccountsTable),
+		Key: map[string]types.AttributeValue{
+			"email": &types.AttributeValueMemberS{Value: account.Email},
+		},
+		UpdateExpression: aws.String("SET retranslating_until = :until"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":until": &types.AttributeValueMemberN{Value: strconv.FormatInt(until.Unix(), 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to renew re-translation: %w", err)
+	}
+	account.RetranslatingUntil = until.Unix()
+	return nil
+}
+
+// ReleaseRetranslation clears the mark set by ClaimRetranslation.
+func (db *DynamoDB) ReleaseRetranslation(ctx context.Context, account *models.Account) error {
+	_, err := db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(db.AccountsTable),
+		Key: map[string]types.AttributeValue{
+			"email": &types.AttributeValueMemberS{Value: account.Email},
+		},
+		UpdateExpression: aws.String("REMOVE retranslating_until"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release re-translation: %w", err)
+	}
+	account.RetranslatingUntil = 0
+	return nil
+}
@@ -12,9 +12,11 @@ type DynamoDB struct {
 	AccountsTable   string
 	PastesTable     string
 	RateLimitsTable string
+	UsageTable      string
+	MemoryTable     string
 }
 
-func NewDynamoDB(ctx context.Context, region, accountsTable, pastesTable, rateLimitsTable string) (*DynamoDB, error) {
+func NewDynamoDB(ctx context.Context, region, accountsTable, pastesTable, rateLimitsTable, usageTable, memoryTable string) (*DynamoDB, error) {
 	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
 	if err != nil {
 		return nil, err
@@ -27,6 +29,8 @@ func NewDynamoDB(ctx context.Context, region, accountsTable, pastesTable, rateLi
 		AccountsTable:   accountsTable,
 		PastesTable:     pastesTable,
 		RateLimitsTable: rateLimitsTable,
+		UsageTable:      usageTable,
+		MemoryTable:     memoryTable,
 	}, nil
 }
 
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/lingopaste/backend/internal/models"
+)
+
+// ErrVersionConflict is returned when a conditional write loses a race with
+// a concurrent update.
+var ErrVersionConflict = errors.New("version conflict")
+
+// SaveGlossary stores glossary on the account item. The write only succeeds
+// if the stored glossary is still at prevVersion, so concurrent edits are
+// detected instead of silently overwritten.
+func (db *DynamoDB) SaveGlossary(ctx context.Context, account *models.Account, glossary *models.Glossary, prevVersion int) error {
+	now := time.Now().Unix()
+	glossary.UpdatedAt = now
+
+	value, err := attributevalue.Marshal(glossary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal glossary: %w", err)
+	}
+
+	_, err = db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(db.AccountsTable),
+		Key: map[string]types.AttributeValue{
+			"email": &types.AttributeValueMemberS{Value: account.Email},
+		},
+		UpdateExpression:    aws.String("SET glossary = :glossary, updated_at = :now"),
+		ConditionExpression: aws.String("attribute_not_exists(glossary) OR glossary.version = :prev"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":glossary": value,
+			":now":      &types.AttributeValueMemberN{Value: strconv.FormatInt(now, 10)},
+			":prev":     &types.AttributeValueMemberN{Value: strconv.Itoa(prevVersion)},
+		},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return ErrVersionConflict
+		}
+		return fmt.Errorf("failed to save glossary: %w", err)
+	}
+
+	account.Glossary = glossary
+	account.UpdatedAt = now
+	return nil
+}
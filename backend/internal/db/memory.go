@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// DynamoDB batch limits.
+	maxBatchGet   = 100
+	maxBatchWrite = 25
+
+	// Unused translation memory entries expire after memoryTTL; every
+	// write refreshes it.
+	memoryTTL = 90 * 24 * time.Hour
+)
+
+// GetMemorySegments returns the stored translations of the given
+// translation memory keys. Unknown keys are absent from the result.
+func (db *DynamoDB) GetMemorySegments(ctx context.Context, keys []string) (map[string]string, error) {
+	segments := make(map[string]string, len(keys))
+
+	for start := 0; start < len(keys); start += maxBatchGet {
+		end := min(start+maxBatchGet, len(keys))
+		requested := make([]map[string]types.AttributeValue, 0, end-start)
+		for _, key := range keys[start:end] {
+			requested = append(requested, map[string]types.AttributeValue{
+				"segment_key": &types.AttributeValueMemberS{Value: key},
+			})
+		}
+
+		request := map[string]types.KeysAndAttributes{
+			db.MemoryTable: {Keys: requested},
+		}
+		for len(request) > 0 {
+			result, err := db.Client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: request})
+			if err != nil {
+				return segments, fmt.Errorf("failed to get memory segments: %w", err)
+			}
+			for _, item := range result.Responses[db.MemoryTable] {
+				key, _ := item["segment_key"].(*types.AttributeValueMemberS)
+				translation, _ := item["translation"].(*types.AttributeValueMemberS)
+				if key != nil && translation != nil {
+					segments[key.Value] = translation.Value
+				}
+			}
+			request = result.UnprocessedKeys
+		}
+	}
+
+	return segments, nil
+}
+
+// PutMemorySegments stores translations under their translation memory keys.
+func (db *DynamoDB) PutMemorySegments(ctx context.Context, segments map[string]string) error {
+	ttl := strconv.FormatInt(time.Now().Add(memoryTTL).Unix(), 10)
+
+	writes := make([]types.WriteRequest, 0, len(segments))
+	for key, translation := range segments {
+		writes = append(writes, types.WriteRequest{
+			PutRequest: &types.PutRequest{
+				Item: map[string]types.AttributeValue{
+					"segment_key": &types.AttributeValueMemberS{Value: key},
+					"translation": &types.AttributeValueMemberS{Value: translation},
+					"ttl":         &types.AttributeValueMemberN{Value: ttl},
+				},
+			},
+		})
+	}
+
+	for start := 0; start < len(writes); start += maxBatchWrite {
+		end := min(start+maxBatchWrite, len(writes))
+		request := map[string][]types.WriteRequest{
+			db.MemoryTable: writes[start:end],
+		}
+		for len(request) > 0 {
+			result, err := db.Client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: request})
+			if err != nil {
+				return fmt.Errorf("failed to put memory segments: %w", err)
+			}
+			request = result.UnprocessedItems
+		}
+	}
+
+	return nil
+}
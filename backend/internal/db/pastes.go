@@ -2,7 +2,9 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -54,6 +56,42 @@ func (db *DynamoDB) GetPasteMeta(ctx context.Context, pasteID string) (*models.P
 	return &meta, nil
 }
 
+// ListPastesByCreator returns up to limit of an account's pastes, newest
+// first.
+func (db *DynamoDB) ListPastesByCreator(ctx context.Context, accountID string, limit int) ([]models.PasteMeta, error) {
+	var pastes []models.PasteMeta
+	var startKey map[string]types.AttributeValue
+
+	for len(pastes) < limit {
+		result, err := db.Client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(db.PastesTable),
+			IndexName:              aws.String("creator_account_id-created_at-index"),
+			KeyConditionExpression: aws.String("creator_account_id = :account_id"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":account_id": &types.AttributeValueMemberS{Value: accountID},
+			},
+			ScanIndexForward:  aws.Bool(false),
+			Limit:             aws.Int32(int32(limit - len(pastes))),
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to query pastes: %w", err)
+		}
+
+		var page []models.PasteMeta
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal paste meta: %w", err)
+		}
+		pastes = append(pastes, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		startKey = result.LastEvaluatedKey
+	}
+	return pastes, nil
+}
+
 func (db *DynamoDB) UpdatePasteMeta(ctx context.Context, meta *models.PasteMeta) error {
 	item, err := attributevalue.MarshalMap(meta)
 	if err != nil {
@@ -71,38 +109,192 @@ func (db *DynamoDB) UpdatePasteMeta(ctx context.Context, meta *models.PasteMeta)
 	return nil
 }
 
-func (db *DynamoDB) AddTranslationLanguage(ctx context.Context, pasteID, language string) error {
-	// First, get the current paste to check if language already exists
-	meta, err := db.GetPasteMeta(ctx, pasteID)
-	if err != nil {
-		return fmt.Errorf("failed to get paste meta: %w", err)
+// StoredTranslation describes a translation that was just saved for a paste.
+type StoredTranslation struct {
+	// Language is set for translations in the paste's own tone, VariantKey
+	// for those in another tone.
+	Language   string
+	VariantKey string
+	Usage      *models.Usage
+}
+
+// maxAddTranslationsAttempts bounds how often AddTranslations re-reads a
+// paste after losing a race with a concurrent writer.
+const maxAddTranslationsAttempts = 3
+
+// AddTranslations records stored translations on a paste: their languages
+// and variant keys are appended to the paste's lists unless already there,
+// their usage is set under their variant key and languages awaiting
+// background translation are marked ready. Batch and background
+// translations write concurrently, so the update is conditional on the
+// paste not having changed in ways that matter, and is rebuilt from a fresh
+// read when it has.
+func (db *DynamoDB) AddTranslations(ctx context.Context, pasteID string, translations []StoredTranslation) error {
+	for attempt := 0; attempt < maxAddTranslationsAttempts; attempt++ {
+		meta, err := db.GetPasteMeta(ctx, pasteID)
+		if err != nil {
+			return fmt.Errorf("failed to get paste meta: %w", err)
+		}
+		if meta == nil {
+			return fmt.Errorf("paste not found")
+		}
+
+		input, err := addTranslationsUpdate(db.PastesTable, meta, translations)
+		if err != nil {
+			return err
+		}
+		if input == nil {
+			return nil
+		}
+
+		_, err = db.Client.UpdateItem(ctx, input)
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add translations: %w", err)
+		}
+		return nil
 	}
-	if meta == nil {
-		return fmt.Errorf("paste not found")
+	return fmt.Errorf("failed to add translations: concurrent update")
+}
+
+// addTranslationsUpdate builds the update recording translations on meta.
+// Its condition fails if another writer appended one of the same keys, or
+// created or removed the usage map, since meta was read.
+func addTranslationsUpdate(table string, meta *models.PasteMeta, translations []StoredTranslation) (*dynamodb.UpdateItemInput, error) {
+	var sets, conditions []string
+	names := make(map[string]string)
+	values := make(map[string]types.AttributeValue)
+
+	var languages, variantKeys []types.AttributeValue
+	usage := make(map[string]types.AttributeValue)
+	seen := make(map[string]bool)
+	for _, translation := range translations {
+		key := translation.VariantKey
+		if translation.Language != "" {
+			key = translation.Language
+			if !seen[key] && !contains(meta.AvailableTranslations, key) {
+				value := fmt.Sprintf(":language%d", len(languages))
+				values[value] = &types.AttributeValueMemberS{Value: key}
+				languages = append(languages, values[value])
+				conditions = append(conditions, fmt.Sprintf("NOT contains(available_translations, %s)", value))
+			}
+			if _, ok := meta.TranslationStatus[key]; ok && !seen[key] {
+				i := len(names)
+				sets = append(sets, fmt.Sprintf("translation_status.#status%d = :ready", i))
+				names[fmt.Sprintf("#status%d", i)] = key
+				values[":ready"] = &types.AttributeValueMemberS{Value: models.TranslationReady}
+			}
+		} else if !seen[key] && !contains(meta.TranslationVariants, key) {
+			value := fmt.Sprintf(":variant%d", len(variantKeys))
+			values[value] = &types.AttributeValueMemberS{Value: key}
+			variantKeys = append(variantKeys, values[value])
+			conditions = append(conditions, fmt.Sprintf("NOT contains(translation_variants, %s)", value))
+		}
+		seen[key] = true
+
+		if translation.Usage != nil {
+			value, err := attributevalue.Marshal(translation.Usage)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal usage: %w", err)
+			}
+			usage[key] = value
+		}
 	}
 
-	// Check if language already in list
-	for _, lang := range meta.AvailableTranslations {
-		if lang == language {
-			return nil // Already exists, nothing to do
+	if len(languages) > 0 {
+		sets = append(sets, "available_translations = list_append(if_not_exists(available_translations, :empty_list), :languages)")
+		values[":languages"] = &types.AttributeValueMemberL{Value: languages}
+	}
+	if len(variantKeys) > 0 {
+		sets = append(sets, "translation_variants = list_append(if_not_exists(translation_variants, :empty_list), :variants)")
+		values[":variants"] = &types.AttributeValueMemberL{Value: variantKeys}
+	}
+	if len(languages) > 0 || len(variantKeys) > 0 {
+		values[":empty_list"] = &types.AttributeValueMemberL{Value: []types.AttributeValue{}}
+	}
+
+	// Nested SETs need the usage map to exist; when it does not, it is
+	// created whole.
+	if len(usage) > 0 {
+		if meta.TranslationUsage != nil {
+			i := 0
+			for key, value := range usage {
+				sets = append(sets, fmt.Sprintf("translation_usage.#usage%d = :usage%d", i, i))
+				names[fmt.Sprintf("#usage%d", i)] = key
+				values[fmt.Sprintf(":usage%d", i)] = value
+				i++
+			}
+			conditions = append(conditions, "attribute_exists(translation_usage)")
+		} else {
+			sets = append(sets, "translation_usage = :usage")
+			values[":usage"] = &types.AttributeValueMemberM{Value: usage}
+			conditions = append(conditions, "attribute_not_exists(translation_usage)")
 		}
 	}
 
-	// Append to list
-	_, err = db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+	if len(sets) == 0 {
+		return nil, nil
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(table),
+		Key: map[string]types.AttributeValue{
+			"paste_id": &types.AttributeValueMemberS{Value: meta.PasteID},
+		},
+		UpdateExpression:          aws.String("SET " + strings.Join(sets, ", ")),
+		ExpressionAttributeValues: values,
+	}
+	if len(conditions) > 0 {
+		input.ConditionExpression = aws.String(strings.Join(conditions, " AND "))
+	}
+	if len(names) > 0 {
+		input.ExpressionAttributeNames = names
+	}
+	return input, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, existing := range list {
+		if existing == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTranslationStatus updates the status of languages awaiting background
+// translation.
+func (db *DynamoDB) SetTranslationStatus(ctx context.Context, pasteID string, statuses map[string]string) error {
+	if len(statuses) == 0 {
+		return nil
+	}
+
+	var sets []string
+	names := make(map[string]string, len(statuses))
+	values := make(map[string]types.AttributeValue, len(statuses))
+	i := 0
+	for language, status := range statuses {
+		sets = append(sets, fmt.Sprintf("translation_status.#language%d = :status%d", i, i))
+		names[fmt.Sprintf("#language%d", i)] = language
+		values[fmt.Sprintf(":status%d", i)] = &types.AttributeValueMemberS{Value: status}
+		i++
+	}
+
+	_, err := db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName: aws.String(db.PastesTable),
 		Key: map[string]types.AttributeValue{
 			"paste_id": &types.AttributeValueMemberS{Value: pasteID},
 		},
-		UpdateExpression: aws.String("SET available_translations = list_append(if_not_exists(available_translations, :empty_list), :lang)"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":lang":       &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberS{Value: language}}},
-			":empty_list": &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
-		},
+		UpdateExpression:          aws.String("SET " + strings.Join(sets, ", ")),
+		ConditionExpression:       aws.String("attribute_exists(translation_status)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to add translation language: %w", err)
+		return fmt.Errorf("failed to set translation status: %w", err)
 	}
-
 	return nil
 }
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/lingopaste/backend/internal/models"
+)
+
+// Identifier types of usage records.
+const (
+	UsageAccount = "account"
+	UsageIP      = "ip"
+)
+
+// AddUsage adds usage to the daily totals of identifier.
+func (db *DynamoDB) AddUsage(ctx context.Context, identifierType, identifier, date string, usage models.Usage) error {
+	_, err := db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(db.UsageTable),
+		Key: map[string]types.AttributeValue{
+			"identifier": &types.AttributeValueMemberS{Value: identifierType + ":" + identifier},
+			"date":       &types.AttributeValueMemberS{Value: date},
+		},
+		UpdateExpression: aws.String("ADD calls :calls, prompt_tokens :prompt, completion_tokens :completion, cost_usd :cost SET identifier_type = :type"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":calls":      &types.AttributeValueMemberN{Value: strconv.Itoa(usage.Calls)},
+			":prompt":     &types.AttributeValueMemberN{Value: strconv.Itoa(usage.PromptTokens)},
+			":completion": &types.AttributeValueMemberN{Value: strconv.Itoa(usage.CompletionTokens)},
+			":cost":       &types.AttributeValueMemberN{Value: strconv.FormatFloat(usage.CostUSD, 'f', -1, 64)},
+			":type":       &types.AttributeValueMemberS{Value: identifierType},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add usage: %w", err)
+	}
+	return nil
+}
+
+// ListUsageByDate returns every usage record of date via the date index.
+func (db *DynamoDB) ListUsageByDate(ctx context.Context, date string) ([]models.UsageRecord, error) {
+	var records []models.UsageRecord
+	var startKey map[string]types.AttributeValue
+
+	for {
+		result, err := db.Client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(db.UsageTable),
+			IndexName:              aws.String("date-index"),
+			KeyConditionExpression: aws.String("#date = :date"),
+			ExpressionAttributeNames: map[string]string{
+				"#date": "date",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":date": &types.AttributeValueMemberS{Value: date},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to query usage: %w", err)
+		}
+
+		var page []models.UsageRecord
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal usage: %w", err)
+		}
+		records = append(records, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return records, nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/lingopaste/backend/internal/cache"
+	"github.com/lingopaste/backend/internal/db"
+	"github.com/lingopaste/backend/internal/middleware"
+	"github.com/lingopaste/backend/internal/models"
+)
+
+// accountCacheTTL bounds how long a cached account may be stale. Entries
+// are only deleted on the instance that modified the account, so other
+// instances, and changes made outside the API such as upgrades to a paid
+// plan, are picked up once the entry expires.
+const accountCacheTTL = time.Minute
+
+type cachedAccountEntry struct {
+	account *models.Account
+	expires time.Time
+}
+
+func accountCacheKey(accountID string) string {
+	return fmt.Sprintf("account:%s", accountID)
+}
+
+// cachedAccount returns the account with the given ID, or nil if there is
+// none. Found accounts are cached for accountCacheTTL; handlers that modify
+// an account must delete its cache entry.
+func cachedAccount(ctx context.Context, database *db.DynamoDB, lru *cache.LRUCache, accountID string) *models.Account {
+	if accountID == "" {
+		return nil
+	}
+
+	cacheKey := accountCacheKey(accountID)
+	if cached, ok := lru.Get(cacheKey); ok {
+		if entry := cached.(cachedAccountEntry); time.Now().Before(entry.expires) {
+			return entry.account
+		}
+		lru.Delete(cacheKey)
+	}
+
+	account, err := database.GetAccountByID(ctx, accountID)
+	if err != nil {
+		log.Printf("Error getting account: %v", err)
+		return nil
+	}
+
+	// Misses are not cached, so an account created since is found.
+	if account != nil {
+		lru.Set(cacheKey, cachedAccountEntry{account: account, expires: time.Now().Add(accountCacheTTL)})
+	}
+	return account
+}
+
+// requireAccount resolves the authenticated account, writing an error
+// response and returning false if there is none.
+func requireAccount(w http.ResponseWriter, r *http.Request, database *db.DynamoDB) (*models.Account, bool) {
+	ctx := r.Context()
+
+	accountID := middleware.GetAccountIDFromContext(ctx)
+	if accountID == "" {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	account, err := database.GetAccountByID(ctx, accountID)
+	if err != nil {
+		log.Printf("Error getting account: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+	if account == nil {
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return account, true
+}
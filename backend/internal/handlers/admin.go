@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lingopaste/backend/internal/db"
+	"github.com/lingopaste/backend/internal/middleware"
+	"github.com/lingopaste/backend/internal/models"
+)
+
+const (
+	maxSpendDays         = 31
+	defaultSpendTopLimit = 20
+)
+
+// AdminHandler serves operator reports to the configured admin accounts.
+type AdminHandler struct {
+	db     *db.DynamoDB
+	admins map[string]bool
+}
+
+func NewAdminHandler(db *db.DynamoDB, adminAccountIDs []string) *AdminHandler {
+	admins := make(map[string]bool, len(adminAccountIDs))
+	for _, id := range adminAccountIDs {
+		admins[id] = true
+	}
+	return &AdminHandler{
+		db:     db,
+		admins: admins,
+	}
+}
+
+// Spend summarizes provider spend between the "from" and "to" dates
+// (YYYY-MM-DD, UTC, inclusive; both default to today) with the biggest
+// spenders by account and by IP hash.
+func (h *AdminHandler) Spend(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	today := time.Now().UTC().Format("2006-01-02")
+	from, err := parseSpendDate(query.Get("from"), today)
+	if err != nil {
+		http.Error(w, "Invalid from date", http.StatusBadRequest)
+		return
+	}
+	to, err := parseSpendDate(query.Get("to"), today)
+	if err != nil {
+		http.Error(w, "Invalid to date", http.StatusBadRequest)
+		return
+	}
+	if to.Before(from) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+	if days := int(to.Sub(from).Hours()/24) + 1; days > maxSpendDays {
+		http.Error(w, fmt.Sprintf("Date range cannot exceed %d days", maxSpendDays), http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultSpendTopLimit
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp := models.SpendSummaryResponse{
+		From: from.Format("2006-01-02"),
+		To:   to.Format("2006-01-02"),
+		Days: []models.DailySpend{},
+	}
+	accounts := make(map[string]*models.SpendTotals)
+	ipHashes := make(map[string]*models.SpendTotals)
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		records, err := h.db.ListUsageByDate(r.Context(), date)
+		if err != nil {
+			log.Printf("Error listing usage: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		daily := models.DailySpend{Date: date}
+		for _, record := range records {
+			switch record.IdentifierType {
+			case db.UsageIP:
+				addSpend(&daily.SpendTotals, record)
+				addSpend(&resp.Total, record)
+				addSpendTo(ipHashes, strings.TrimPrefix(record.Identifier, db.UsageIP+":"), record)
+			case db.UsageAccount:
+				addSpendTo(accounts, strings.TrimPrefix(record.Identifier, db.UsageAccount+":"), record)
+			}
+		}
+		resp.Days = append(resp.Days, daily)
+	}
+
+	resp.TopAccounts = topSpenders(accounts, limit)
+	resp.TopIPHashes = topSpenders(ipHashes, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Vars serves the runtime metrics published with expvar, such as
+// translation retries, circuit breaker state and translation memory hit
+// rate. They include the process command line, so only admins see them.
+func (h *AdminHandler) Vars(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	expvar.Handler().ServeHTTP(w, r)
+}
+
+func (h *AdminHandler) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	accountID := middleware.GetAccountIDFromContext(r.Context())
+	if accountID == "" {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return false
+	}
+	if !h.admins[accountID] {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func parseSpendDate(value, fallback string) (time.Time, error) {
+	if value == "" {
+		value = fallback
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func addSpend(totals *models.SpendTotals, record models.UsageRecord) {
+	totals.Calls += record.Calls
+	totals.PromptTokens += record.PromptTokens
+	totals.CompletionTokens += record.CompletionTokens
+	totals.CostUSD += record.CostUSD
+}
+
+func addSpendTo(byIdentifier map[string]*models.SpendTotals, identifier string, record models.UsageRecord) {
+	totals, ok := byIdentifier[identifier]
+	if !ok {
+		totals = &models.SpendTotals{}
+		byIdentifier[identifier] = totals
+	}
+	addSpend(totals, record)
+}
+
+// topSpenders returns the limit identifiers with the highest cost.
+func topSpenders(byIdentifier map[string]*models.SpendTotals, limit int) []models.SpendEntry {
+	entries := make([]models.SpendEntry, 0, len(byIdentifier))
+	for identifier, totals := range byIdentifier {
+		entries = append(entries, models.SpendEntry{Identifier: identifier, SpendTotals: *totals})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].CostUSD != entries[j].CostUSD {
+			return entries[i].CostUSD > entries[j].CostUSD
+		}
+		return entries[i].Identifier < entries[j].Identifier
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/lingopaste/backend/internal/models"
+	"github.com/lingopaste/backend/internal/translate"
+)
+
+// storedAlignment is the alignment persisted next to a translation. The
+// checksum identifies the translation it was computed from, so a
+// replaced translation never gets a stale alignment.
+type storedAlignment struct {
+	Checksum string               `json:"checksum"`
+	Pairs    []models.AlignedPair `json:"pairs"`
+}
+
+// Align returns the original and a stored translation, selected like
+// Translate by "lang", "tone" and "formality", with their sentences paired
+// up for reading side by side.
+func (h *PasteHandler) Align(w http.ResponseWriter, r *http.Request) {
+	meta, variant, ok := h.parseTranslateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	translation, ok := h.loadTranslation(ctx, meta.PasteID, variant.Key)
+	if !ok {
+		http.Error(w, "Translation not found", http.StatusNotFound)
+		return
+	}
+
+	original, err := h.loadOriginal(ctx, meta)
+	if err != nil {
+		log.Printf("Error getting original from S3: %v", err)
+		http.Error(w, "Failed to load paste", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.AlignmentResponse{
+		Language:    variant.Language,
+		Tone:        variant.Tone,
+		Formality:   variant.Formality,
+		Original:    original,
+		Translation: translation,
+		Pairs:       h.alignment(ctx, meta.PasteID, variant.Key, original, translation),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// alignment returns the stored alignment of a translation if it was
+// computed from this translation, and computes and stores it otherwise.
+func (h *PasteHandler) alignment(ctx context.Context, pasteID, variantKey, original, translation string) []models.AlignedPair {
+	sum := sha256.Sum256([]byte(translation))
+	checksum := hex.EncodeToString(sum[:])
+
+	cacheKey := fmt.Sprintf("%s:%s:alignment", pasteID, variantKey)
+	if cached, ok := h.cache.Get(cacheKey); ok {
+		if stored := cached.(*storedAlignment); stored.Checksum == checksum {
+			return stored.Pairs
+		}
+	}
+
+	if data, err := h.storage.GetAlignment(ctx, pasteID, variantKey); err == nil {
+		var stored storedAlignment
+		if err := json.Unmarshal(data, &stored); err == nil && stored.Checksum == checksum {
+			h.cache.Set(cacheKey, &stored)
+			return stored.Pairs
+		}
+	}
+
+	stored := &storedAlignment{
+		Checksum: checksum,
+		Pairs:    translate.Align(original, translation),
+	}
+	if data, err := json.Marshal(stored); err != nil {
+		log.Printf("Error encoding alignment: %v", err)
+	} else if err := h.storage.SaveAlignment(ctx, pasteID, variantKey, data); err != nil {
+		log.Printf("Error saving alignment to S3: %v", err)
+	}
+	h.cache.Set(cacheKey, stored)
+	return stored.Pairs
+}
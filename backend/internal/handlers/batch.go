@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lingopaste/backend/internal/models"
+	"github.com/lingopaste/backend/internal/translate"
+)
+
+const maxBatchLanguages = 20
+
+// TranslateBatch translates a paste into several languages at once, given
+// either as the comma-separated "langs" query parameter or as a
+// BatchTranslateRequest body. Languages are translated concurrently; every
+// one gets a result or an error, and the fresh translations are recorded on
+// the paste in a single write.
+func (h *PasteHandler) TranslateBatch(w http.ResponseWriter, r *http.Request) {
+	pasteID := mux.Vars(r)["id"]
+	if pasteID == "" {
+		http.Error(w, "Paste ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var req models.BatchTranslateRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	} else {
+		req.Languages = strings.Split(r.URL.Query().Get("langs"), ",")
+		req.Tone = r.URL.Query().Get("tone")
+		req.Formality = r.URL.Query().Get("formality")
+	}
+	if !translate.IsValidFormality(req.Formality) {
+		http.Error(w, formalityError(translate.ErrInvalidFormality), http.StatusBadRequest)
+		return
+	}
+
+	languages := uniqueLanguages(req.Languages)
+	if len(languages) == 0 {
+		http.Error(w, "At least one language is required", http.StatusBadRequest)
+		return
+	}
+	if len(languages) > maxBatchLanguages {
+		http.Error(w, fmt.Sprintf("Cannot translate into more than %d languages at once", maxBatchLanguages), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	meta, err := h.getMeta(ctx, pasteID)
+	if err != nil {
+		log.Printf("Error getting paste metadata: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if meta == nil {
+		http.Error(w, "Paste not found", http.StatusNotFound)
+		return
+	}
+
+	tone, ok := h.resolveTone(ctx, meta, req.Tone)
+	if !ok {
+		http.Error(w, "Invalid tone", http.StatusBadRequest)
+		return
+	}
+
+	// A batch can take much longer than a single translation.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		log.Printf("Error extending write deadline: %v", err)
+	}
+
+	resp := models.BatchTranslateResponse{
+		Results: make(map[string]models.TranslateResponse),
+		Errors:  make(map[string]models.TranslateError),
+	}
+
+	variants := make(map[string]models.TranslationVariant)
+	for _, requested := range languages {
+		targetLang, err := translate.NormalizeTag(requested)
+		if err != nil {
+			resp.Errors[requested] = models.TranslateError{Error: languageTagError(err)}
+			continue
+		}
+		if err := translate.CheckFormality(targetLang, req.Formality); err != nil {
+			resp.Errors[requested] = models.TranslateError{Error: formalityError(err)}
+			continue
+		}
+		variants[targetLang] = h.variantFor(meta, targetLang, tone, req.Formality)
+	}
+
+	outcomes := h.translateAll(ctx, meta, variants)
+
+	var fresh []freshTranslation
+	for language, outcome := range outcomes {
+		if outcome.err != nil {
+			log.Printf("Error translating paste %s into %s: %v", pasteID, language, outcome.err)
+			resp.Errors[language] = translateError(outcome.err)
+			continue
+		}
+		resp.Results[language] = outcome.result
+		if outcome.fresh != nil {
+			fresh = append(fresh, *outcome.fresh)
+		}
+	}
+	if len(fresh) > 0 {
+		h.storeTranslations(ctx, pasteID, fresh)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// batchOutcome is the result of translating a paste into one language.
+type batchOutcome struct {
+	result models.TranslateResponse
+	fresh  *freshTranslation
+	err    error
+}
+
+// translateAll translates a paste into every variant, keyed by language,
+// with at most batchLimit translations in flight. The original is loaded
+// once, and only if some variant is not stored yet.
+func (h *PasteHandler) translateAll(ctx context.Context, meta *models.PasteMeta, variants map[string]models.TranslationVariant) map[string]batchOutcome {
+	original := sync.OnceValues(func() (string, error) {
+		return h.storage.GetOriginal(ctx, meta.PasteID)
+	})
+
+	outcomes := make(map[string]batchOutcome, len(variants))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, h.batchLimit)
+
+	for language, variant := range variants {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			result, fresh, err := h.translateVariant(ctx, meta, variant, original)
+			mu.Lock()
+			outcomes[language] = batchOutcome{result: result, fresh: fresh, err: err}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return outcomes
+}
+
+// translateError describes a failed translation for a batch response.
+func translateError(err error) models.TranslateError {
+	if retryAfter, ok := unavailable(err); ok {
+		return models.TranslateError{
+			Error:      "Translation service temporarily unavailable",
+			RetryAfter: retryAfter,
+		}
+	}
+	if errors.Is(err, translate.ErrSuspiciousOutput) {
+		return models.TranslateError{Error: "Translation was rejected by quality checks"}
+	}
+	if errors.Is(err, translate.ErrStructureMismatch) {
+		return models.TranslateError{Error: "Translation did not keep the structure of the file"}
+	}
+	return models.TranslateError{Error: "Translation failed"}
+}
+
+// uniqueLanguages trims the requested languages and drops blanks and
+// duplicates, keeping the first occurrence.
+func uniqueLanguages(requested []string) []string {
+	seen := make(map[string]bool, len(requested))
+	var languages []string
+	for _, language := range requested {
+		language = strings.TrimSpace(language)
+		if language == "" || seen[language] {
+			continue
+		}
+		seen[language] = true
+		languages = append(languages, language)
+	}
+	return languages
+}
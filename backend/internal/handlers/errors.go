@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/lingopaste/backend/internal/translate"
+)
+
+// unavailable reports whether err means the translation provider is
+// currently unhealthy, along with how many seconds clients should wait.
+func unavailable(err error) (int, bool) {
+	var unavailableErr *translate.UnavailableError
+	if !errors.As(err, &unavailableErr) {
+		return 0, false
+	}
+	seconds := int(math.Ceil(unavailableErr.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds, true
+}
+
+// writeTranslatorError responds with 503 and Retry-After when the provider
+// is unavailable, 502 when its output kept failing sanity checks or could not
+// be fitted back into a structured file and a 500 carrying message otherwise.
+func writeTranslatorError(w http.ResponseWriter, err error, message string) {
+	if retryAfter, ok := unavailable(err); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		http.Error(w, "Translation service temporarily unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if errors.Is(err, translate.ErrSuspiciousOutput) {
+		http.Error(w, "Translation was rejected by quality checks, please try again", http.StatusBadGateway)
+		return
+	}
+	if errors.Is(err, translate.ErrStructureMismatch) {
+		http.Error(w, "Translation did not keep the structure of the file, please try again", http.StatusBadGateway)
+		return
+	}
+	http.Error(w, message, http.StatusInternalServerError)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lingopaste/backend/internal/cache"
+	"github.com/lingopaste/backend/internal/db"
+	"github.com/lingopaste/backend/internal/models"
+	"github.com/lingopaste/backend/internal/translate"
+)
+
+const (
+	maxGlossaryEntries = 500
+	maxGlossaryTermLen = 200
+)
+
+// GlossaryHandler manages the glossary of the authenticated account.
+type GlossaryHandler struct {
+	db    *db.DynamoDB
+	cache *cache.LRUCache
+}
+
+func NewGlossaryHandler(db *db.DynamoDB, cache *cache.LRUCache) *GlossaryHandler {
+	return &GlossaryHandler{
+		db:    db,
+		cache: cache,
+	}
+}
+
+func (h *GlossaryHandler) Get(w http.ResponseWriter, r *http.Request) {
+	account, ok := requireAccount(w, r, h.db)
+	if !ok {
+		return
+	}
+
+	glossary := account.Glossary
+	if glossary == nil {
+		glossary = &models.Glossary{Entries: []models.GlossaryEntry{}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(glossary)
+}
+
+// Replace overwrites every entry of the glossary.
+func (h *GlossaryHandler) Replace(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateGlossaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Entries) > maxGlossaryEntries {
+		http.Error(w, fmt.Sprintf("Glossary cannot have more than %d entries", maxGlossaryEntries), http.StatusBadRequest)
+		return
+	}
+
+	seen := make(map[string]bool)
+	for i := range req.Entries {
+		entry := &req.Entries[i]
+		if err := normalizeGlossaryEntry(entry); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		key := strings.ToLower(entry.Term)
+		if seen[key] {
+			http.Error(w, fmt.Sprintf("Duplicate glossary term %q", entry.Term), http.StatusBadRequest)
+			return
+		}
+		seen[key] = true
+	}
+
+	account, ok := requireAccount(w, r, h.db)
+	if !ok {
+		return
+	}
+
+	h.save(w, r, account, req.Entries)
+}
+
+// PutTerm creates or updates a single glossary entry.
+func (h *GlossaryHandler) PutTerm(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateGlossaryTermRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	entry := models.GlossaryEntry{
+		Term:           mux.Vars(r)["term"],
+		DoNotTranslate: req.DoNotTranslate,
+		Translations:   req.Translations,
+	}
+	if err := normalizeGlossaryEntry(&entry); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	account, ok := requireAccount(w, r, h.db)
+	if !ok {
+		return
+	}
+
+	var entries []models.GlossaryEntry
+	if account.Glossary != nil {
+		entries = account.Glossary.Entries
+	}
+
+	replaced := false
+	updated := make([]models.GlossaryEntry, 0, len(entries)+1)
+	for _, existing := range entries {
+		if strings.EqualFold(existing.Term, entry.Term) {
+			updated = append(updated, entry)
+			replaced = true
+			continue
+		}
+		updated = append(updated, existing)
+	}
+	if !replaced {
+		if len(updated) >= maxGlossaryEntries {
+			http.Error(w, fmt.Sprintf("Glossary cannot have more than %d entries", maxGlossaryEntries), http.StatusBadRequest)
+			return
+		}
+		updated = append(updated, entry)
+	}
+
+	h.save(w, r, account, updated)
+}
+
+func (h *GlossaryHandler) DeleteTerm(w http.ResponseWriter, r *http.Request) {
+	term := strings.TrimSpace(mux.Vars(r)["term"])
+
+	account, ok := requireAccount(w, r, h.db)
+	if !ok {
+		return
+	}
+
+	var entries []models.GlossaryEntry
+	if account.Glossary != nil {
+		entries = account.Glossary.Entries
+	}
+
+	updated := make([]models.GlossaryEntry, 0, len(entries))
+	for _, existing := range entries {
+		if !strings.EqualFold(existing.Term, term) {
+			updated = append(updated, existing)
+		}
+	}
+	if len(updated) == len(entries) {
+		http.Error(w, "Glossary term not found", http.StatusNotFound)
+		return
+	}
+
+	h.save(w, r, account, updated)
+}
+
+func (h *GlossaryHandler) save(w http.ResponseWriter, r *http.Request, account *models.Account, entries []models.GlossaryEntry) {
+	prevVersion := 0
+	if account.Glossary != nil {
+		prevVersion = account.Glossary.Version
+	}
+
+	glossary := &models.Glossary{
+		Version: prevVersion + 1,
+		Entries: entries,
+	}
+
+	if err := h.db.SaveGlossary(r.Context(), account, glossary, prevVersion); err != nil {
+		if errors.Is(err, db.ErrVersionConflict) {
+			http.Error(w, "Glossary was modified concurrently, please retry", http.StatusConflict)
+			return
+		}
+		log.Printf("Error saving glossary: %v", err)
+		http.Error(w, "Failed to save glossary", http.StatusInternalServerError)
+		return
+	}
+
+	h.cache.Delete(accountCacheKey(account.AccountID))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(glossary)
+}
+
+// normalizeGlossaryEntry trims and validates an entry in place.
+func normalizeGlossaryEntry(entry *models.GlossaryEntry) error {
+	entry.Term = strings.TrimSpace(entry.Term)
+	if entry.Term == "" {
+		return fmt.Errorf("glossary term is required")
+	}
+	if len(entry.Term) > maxGlossaryTermLen {
+		return fmt.Errorf("glossary term exceeds %d characters", maxGlossaryTermLen)
+	}
+
+	translations := make(map[string]string, len(entry.Translations))
+	for lang, target := range entry.Translations {
+		tag, err := translate.NormalizeTag(lang)
+		if err != nil {
+			return fmt.Errorf("glossary term %q: %v", entry.Term, err)
+		}
+		target = strings.TrimSpace(target)
+		if target == "" {
+			return fmt.Errorf("glossary term %q has an empty translation", entry.Term)
+		}
+		if len(target) > maxGlossaryTermLen {
+			return fmt.Errorf("glossary translation exceeds %d characters", maxGlossaryTermLen)
+		}
+		translations[tag] = target
+	}
+	entry.Translations = translations
+
+	if entry.DoNotTranslate {
+		entry.Translations = nil
+	} else if len(entry.Translations) == 0 {
+		return fmt.Errorf("glossary term %q needs translations or do_not_translate", entry.Term)
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/lingopaste/backend/internal/models"
+	"github.com/lingopaste/backend/internal/translate"
+)
+
+// ListLanguages returns the languages pastes can be translated into.
+func ListLanguages(w http.ResponseWriter, r *http.Request) {
+	resp := models.ListLanguagesResponse{
+		Languages: translate.Languages(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	json.NewEncoder(w).Encode(resp)
+}
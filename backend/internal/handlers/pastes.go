@@ -1,11 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/lingopaste/backend/internal/cache"
@@ -18,26 +19,35 @@ import (
 )
 
 type PasteHandler struct {
-	db         *db.DynamoDB
-	storage    *storage.S3Storage
-	cache      *cache.LRUCache
-	translator *translate.OpenAITranslator
-	maxLength  int
+	db            *db.DynamoDB
+	storage       *storage.S3Storage
+	cache         *cache.LRUCache
+	translator    translate.Translator
+	maxLength     int
+	maxPaidLength int
+	batchLimit    int
 }
 
 func NewPasteHandler(
 	db *db.DynamoDB,
 	storage *storage.S3Storage,
 	cache *cache.LRUCache,
-	translator *translate.OpenAITranslator,
+	translator translate.Translator,
 	maxLength int,
+	maxPaidLength int,
+	batchConcurrency int,
 ) *PasteHandler {
+	if batchConcurrency < 1 {
+		batchConcurrency = 1
+	}
 	return &PasteHandler{
-		db:         db,
-		storage:    storage,
-		cache:      cache,
-		translator: translator,
-		maxLength:  maxLength,
+		db:            db,
+		storage:       storage,
+		cache:         cache,
+		translator:    translator,
+		maxLength:     maxLength,
+		maxPaidLength: maxPaidLength,
+		batchLimit:    batchConcurrency,
 	}
 }
 
@@ -54,8 +64,16 @@ func (h *PasteHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.Content) > h.maxLength {
-		http.Error(w, fmt.Sprintf("Content exceeds maximum length of %d characters", h.maxLength), http.StatusBadRequest)
+	ctx := r.Context()
+
+	// Get IP and account info
+	ip := middleware.GetIPFromContext(ctx)
+	ipHash := utils.HashIP(ip)
+	accountID := middleware.GetAccountIDFromContext(ctx)
+
+	maxLength := h.maxLengthFor(ctx, accountID)
+	if len(req.Content) > maxLength {
+		http.Error(w, fmt.Sprintf("Content exceeds maximum length of %d characters", maxLength), http.StatusBadRequest)
 		return
 	}
 
@@ -63,13 +81,34 @@ func (h *PasteHandler) Create(w http.ResponseWriter, r *http.Request) {
 		req.Tone = "default"
 	}
 
-	validTones := map[string]bool{"default": true, "professional": true, "friendly": true, "brusque": true}
-	if !validTones[req.Tone] {
-		http.Error(w, "Invalid tone. Must be: default, professional, friendly, or brusque", http.StatusBadRequest)
+	if !translate.IsBuiltinTone(req.Tone) {
+		creator := cachedAccount(ctx, h.db, h.cache, accountID)
+		if creator == nil || translate.FindCustomTone(creator.CustomTones, req.Tone) == nil {
+			http.Error(w, "Invalid tone. Must be: default, professional, friendly, brusque, or one of your custom tones", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !translate.IsValidFormality(req.Formality) {
+		http.Error(w, "Invalid formality. Must be: informal, formal or honorific", http.StatusBadRequest)
 		return
 	}
 
-	ctx := r.Context()
+	targetLanguages, err := normalizeTargetLanguages(req.TargetLanguages)
+	if err != nil {
+		http.Error(w, languageTagError(err), http.StatusBadRequest)
+		return
+	}
+	if len(targetLanguages) > maxBatchLanguages {
+		http.Error(w, fmt.Sprintf("Cannot request more than %d target languages", maxBatchLanguages), http.StatusBadRequest)
+		return
+	}
+	for _, language := range targetLanguages {
+		if err := translate.CheckFormality(language, req.Formality); err != nil {
+			http.Error(w, fmt.Sprintf("%s: %s", formalityError(err), language), http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Generate paste ID
 	pasteID, err := utils.GeneratePasteID(8)
@@ -80,13 +119,26 @@ func (h *PasteHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Detect original language
-	originalLang, err := h.translator.DetectLanguage(ctx, req.Content)
+	tier := h.tierFor(ctx, accountID)
+	meterCtx, meter := translate.WithMeter(translate.WithTier(ctx, tier))
+	originalLang, err := h.translator.DetectLanguage(meterCtx, req.Content)
+	detectionUsage := meter.Usage()
+	detectionUsage.Tier = tier
+	recordUsage(ctx, h.db, detectionUsage)
 	if err != nil {
 		log.Printf("Error detecting language: %v", err)
-		http.Error(w, "Failed to detect language", http.StatusInternalServerError)
+		if errors.Is(err, translate.ErrUnknownLanguage) {
+			http.Error(w, "Could not detect the language of this paste", http.StatusUnprocessableEntity)
+			return
+		}
+		writeTranslatorError(w, err, "Failed to detect language")
+		return
+	}
+	originalLang, ok := translate.NormalizeLanguageCode(originalLang)
+	if !ok {
+		http.Error(w, "Could not detect the language of this paste", http.StatusUnprocessableEntity)
 		return
 	}
-	originalLang = strings.TrimSpace(strings.ToLower(originalLang))
 
 	// Save original to S3
 	if err := h.storage.SaveOriginal(ctx, pasteID, req.Content); err != nil {
@@ -95,22 +147,36 @@ func (h *PasteHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get IP and account info
-	ip := middleware.GetIPFromContext(ctx)
-	ipHash := utils.HashIP(ip)
-	// TODO: Get account ID from JWT when auth is implemented
-	accountID := ""
-
 	// Create metadata
 	meta := &models.PasteMeta{
 		PasteID:               pasteID,
 		OriginalLanguage:      originalLang,
 		Tone:                  req.Tone,
+		Formality:             req.Formality,
+		TranslateCodeComments: req.TranslateCodeComments,
 		CreatorIPHash:         ipHash,
 		CreatorAccountID:      accountID,
 		CharacterCount:        len(req.Content),
 		AvailableTranslations: []string{originalLang},
 	}
+	if format := translate.DetectFormat(req.Content); format != translate.FormatText {
+		meta.Format = format
+	}
+	if detectionUsage.Calls > 0 {
+		meta.DetectionUsage = &detectionUsage
+	}
+
+	var pending []string
+	for _, language := range targetLanguages {
+		if language == originalLang {
+			continue
+		}
+		if meta.TranslationStatus == nil {
+			meta.TranslationStatus = make(map[string]string)
+		}
+		meta.TranslationStatus[language] = models.TranslationPending
+		pending = append(pending, language)
+	}
 
 	if err := h.db.CreatePasteMeta(ctx, meta); err != nil {
 		log.Printf("Error saving paste metadata: %v", err)
@@ -125,10 +191,15 @@ func (h *PasteHandler) Create(w http.ResponseWriter, r *http.Request) {
 	// Cache metadata
 	h.cache.Set(fmt.Sprintf("meta:%s", pasteID), meta)
 
+	if len(pending) > 0 {
+		go h.pretranslate(context.WithoutCancel(ctx), meta, pending)
+	}
+
 	resp := models.CreatePasteResponse{
 		PasteID:            pasteID,
 		OriginalLanguage:   originalLang,
 		AvailableLanguages: []string{originalLang},
+		PendingLanguages:   pending,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -146,159 +217,422 @@ func (h *PasteHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	// Try to get metadata from cache
-	var meta *models.PasteMeta
-	if cached, ok := h.cache.Get(fmt.Sprintf("meta:%s", pasteID)); ok {
-		meta = cached.(*models.PasteMeta)
-	} else {
-		// Load from database
-		var err error
-		meta, err = h.db.GetPasteMeta(ctx, pasteID)
-		if err != nil {
-			log.Printf("Error getting paste metadata: %v", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		if meta == nil {
-			http.Error(w, "Paste not found", http.StatusNotFound)
-			return
-		}
-		// Cache it
-		h.cache.Set(fmt.Sprintf("meta:%s", pasteID), meta)
+	meta, err := h.getMeta(ctx, pasteID)
+	if err != nil {
+		log.Printf("Error getting paste metadata: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if meta == nil {
+		http.Error(w, "Paste not found", http.StatusNotFound)
+		return
 	}
 
 	// Get original content
-	var original string
-	cacheKey := fmt.Sprintf("%s:%s", pasteID, meta.OriginalLanguage)
-	if cached, ok := h.cache.Get(cacheKey); ok {
-		original = cached.(string)
-	} else {
-		var err error
-		original, err = h.storage.GetOriginal(ctx, pasteID)
-		if err != nil {
-			log.Printf("Error getting original from S3: %v", err)
-			http.Error(w, "Failed to load paste", http.StatusInternalServerError)
-			return
-		}
-		h.cache.Set(cacheKey, original)
+	original, err := h.loadOriginal(ctx, meta)
+	if err != nil {
+		log.Printf("Error getting original from S3: %v", err)
+		http.Error(w, "Failed to load paste", http.StatusInternalServerError)
+		return
 	}
 
 	// Load all available translations
 	translations := make(map[string]string)
 	translations[meta.OriginalLanguage] = original
 
+	variants := make([]models.TranslationVariant, 0, len(meta.AvailableTranslations)+len(meta.TranslationVariants))
 	for _, lang := range meta.AvailableTranslations {
+		variants = append(variants, models.NewTranslationVariant(lang, meta.Tone, meta.Formality, meta.Tone, meta.Formality))
 		if lang == meta.OriginalLanguage {
 			continue
 		}
 
-		langCacheKey := fmt.Sprintf("%s:%s", pasteID, lang)
-		if cached, ok := h.cache.Get(langCacheKey); ok {
-			translations[lang] = cached.(string)
-		} else {
-			// Try to load from S3
-			trans, err := h.storage.GetTranslation(ctx, pasteID, lang)
-			if err == nil {
-				translations[lang] = trans
-				h.cache.Set(langCacheKey, trans)
-			}
+		if trans, ok := h.loadTranslation(ctx, pasteID, lang); ok {
+			translations[lang] = trans
 		}
 	}
+	for _, key := range meta.TranslationVariants {
+		variants = append(variants, models.ParseVariantKey(key, meta.Tone, meta.Formality))
+	}
 
 	resp := models.GetPasteResponse{
 		PasteID:               pasteID,
 		OriginalLanguage:      meta.OriginalLanguage,
 		Tone:                  meta.Tone,
+		Formality:             meta.Formality,
+		Format:                meta.Format,
 		CreatedAt:             meta.CreatedAt,
 		Original:              original,
 		Translations:          translations,
 		AvailableTranslations: meta.AvailableTranslations,
+		Variants:              variants,
+		TranslationStatus:     translationStatus(meta),
+	}
+
+	if wantsRomanization(r) {
+		translated := make(map[string]string, len(translations))
+		for lang, translation := range translations {
+			if lang != meta.OriginalLanguage {
+				translated[lang] = translation
+			}
+		}
+		resp.Romanizations = h.romanizeAll(ctx, meta, translated)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
+// Translate returns the translation of a paste into the "lang" query
+// parameter, producing it on first request. An optional "tone" or
+// "formality" renders the paste in a tone or formality other than the one
+// it was created with; each combination is stored as a separate variant.
+// With "romanized=true", the response also carries the translation in
+// Latin script.
 func (h *PasteHandler) Translate(w http.ResponseWriter, r *http.Request) {
+	meta, variant, ok := h.parseTranslateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	romanize := wantsRomanization(r)
+	if _, ok := translate.Romanization(variant.Language); romanize && !ok {
+		http.Error(w, "Romanization is not available for this language", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	original := func() (string, error) {
+		return h.storage.GetOriginal(ctx, meta.PasteID)
+	}
+	resp, fresh, err := h.translateVariant(ctx, meta, variant, original)
+	if err != nil {
+		log.Printf("Error translating: %v", err)
+		writeTranslatorError(w, err, "Translation failed")
+		return
+	}
+	if fresh != nil {
+		h.storeTranslations(ctx, meta.PasteID, []freshTranslation{*fresh})
+	}
+
+	if romanize {
+		resp.Romanized, err = h.romanize(ctx, meta, variant, resp.Translation)
+		if err != nil {
+			log.Printf("Error romanizing: %v", err)
+			writeTranslatorError(w, err, "Romanization failed")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// translateVariant returns the stored translation of variant, or produces
+// one from the original. Fresh translations are returned for the caller to
+// store.
+func (h *PasteHandler) translateVariant(ctx context.Context, meta *models.PasteMeta, variant models.TranslationVariant, original func() (string, error)) (models.TranslateResponse, *freshTranslation, error) {
+	resp := models.TranslateResponse{
+		Language:  variant.Language,
+		Tone:      variant.Tone,
+		Formality: variant.Formality,
+	}
+
+	if translation, ok := h.loadTranslation(ctx, meta.PasteID, variant.Key); ok {
+		resp.Translation = translation
+		return resp, nil, nil
+	}
+
+	return h.produceTranslation(ctx, meta, variant, original)
+}
+
+// produceTranslation translates the original into variant with the models
+// of the creator's tier, regardless of any stored translation.
+func (h *PasteHandler) produceTranslation(ctx context.Context, meta *models.PasteMeta, variant models.TranslationVariant, original func() (string, error)) (models.TranslateResponse, *freshTranslation, error) {
+	resp := models.TranslateResponse{
+		Language:  variant.Language,
+		Tone:      variant.Tone,
+		Formality: variant.Formality,
+	}
+
+	text, err := original()
+	if err != nil {
+		return resp, nil, fmt.Errorf("failed to load original: %w", err)
+	}
+
+	opts := h.translateOptions(ctx, meta, variant, text)
+	tier := h.tierFor(ctx, meta.CreatorAccountID)
+	meterCtx, meter := translate.WithMeter(translate.WithTier(ctx, tier))
+	translation, err := h.translator.Translate(meterCtx, text, opts)
+	usage := meter.Usage()
+	usage.Tier = tier
+	recordUsage(ctx, h.db, usage)
+	if err != nil {
+		return resp, nil, err
+	}
+
+	resp.Translation = translation
+	resp.GlossaryViolations = h.checkGlossary(meta.PasteID, variant.Key, translation, opts)
+	resp.PlaceholderMismatches = h.checkPlaceholders(meta.PasteID, variant.Key, text, translation)
+	return resp, &freshTranslation{variant, translation, usage}, nil
+}
+
+// parseTranslateRequest validates the paste ID, "lang" and optional "tone"
+// and "formality" of a translate request, writing an error response if they
+// are invalid.
+func (h *PasteHandler) parseTranslateRequest(w http.ResponseWriter, r *http.Request) (*models.PasteMeta, models.TranslationVariant, bool) {
 	vars := mux.Vars(r)
 	pasteID := vars["id"]
 	targetLang := r.URL.Query().Get("lang")
+	tone := r.URL.Query().Get("tone")
+	formality := r.URL.Query().Get("formality")
 
 	if pasteID == "" || targetLang == "" {
 		http.Error(w, "Paste ID and language are required", http.StatusBadRequest)
-		return
+		return nil, models.TranslationVariant{}, false
 	}
 
-	targetLang = strings.TrimSpace(strings.ToLower(targetLang))
+	targetLang, err := translate.NormalizeTag(targetLang)
+	if err != nil {
+		http.Error(w, languageTagError(err), http.StatusBadRequest)
+		return nil, models.TranslationVariant{}, false
+	}
+	if err := translate.CheckFormality(targetLang, formality); err != nil {
+		http.Error(w, formalityError(err), http.StatusBadRequest)
+		return nil, models.TranslationVariant{}, false
+	}
 
 	ctx := r.Context()
 
-	// Check cache first
-	cacheKey := fmt.Sprintf("%s:%s", pasteID, targetLang)
-	if cached, ok := h.cache.Get(cacheKey); ok {
-		resp := models.TranslateResponse{
-			Language:    targetLang,
-			Translation: cached.(string),
-		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(resp)
-		return
+	meta, err := h.getMeta(ctx, pasteID)
+	if err != nil {
+		log.Printf("Error getting paste metadata: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, models.TranslationVariant{}, false
+	}
+	if meta == nil {
+		http.Error(w, "Paste not found", http.StatusNotFound)
+		return nil, models.TranslationVariant{}, false
+	}
+
+	tone, ok := h.resolveTone(ctx, meta, tone)
+	if !ok {
+		http.Error(w, "Invalid tone", http.StatusBadRequest)
+		return nil, models.TranslationVariant{}, false
+	}
+
+	return meta, h.variantFor(meta, targetLang, tone, formality), true
+}
+
+// variantFor returns the variant of a paste in language and tone. An empty
+// formality keeps the paste's own, which languages without formality
+// levels simply ignore.
+func (h *PasteHandler) variantFor(meta *models.PasteMeta, language, tone, formality string) models.TranslationVariant {
+	if formality == "" {
+		formality = meta.Formality
 	}
+	return models.NewTranslationVariant(language, tone, formality, meta.Tone, meta.Formality)
+}
 
-	// Try to load from S3
-	translation, err := h.storage.GetTranslation(ctx, pasteID, targetLang)
-	if err == nil {
-		h.cache.Set(cacheKey, translation)
-		resp := models.TranslateResponse{
-			Language:    targetLang,
-			Translation: translation,
+// resolveTone returns the tone a translation of the paste is requested in,
+// defaulting to the paste's own, and whether it is one the creator can use.
+func (h *PasteHandler) resolveTone(ctx context.Context, meta *models.PasteMeta, tone string) (string, bool) {
+	if tone == "" {
+		return meta.Tone, true
+	}
+	if tone != meta.Tone && !translate.IsBuiltinTone(tone) {
+		creator := cachedAccount(ctx, h.db, h.cache, meta.CreatorAccountID)
+		if creator == nil || translate.FindCustomTone(creator.CustomTones, tone) == nil {
+			return "", false
 		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(resp)
-		return
+	}
+	return tone, true
+}
+
+// getMeta returns the metadata of a paste, or nil if it does not exist.
+func (h *PasteHandler) getMeta(ctx context.Context, pasteID string) (*models.PasteMeta, error) {
+	cacheKey := fmt.Sprintf("meta:%s", pasteID)
+	if cached, ok := h.cache.Get(cacheKey); ok {
+		return cached.(*models.PasteMeta), nil
 	}
 
-	// Need to translate - get metadata and original
 	meta, err := h.db.GetPasteMeta(ctx, pasteID)
-	if err != nil || meta == nil {
-		http.Error(w, "Paste not found", http.StatusNotFound)
-		return
+	if err != nil {
+		return nil, err
+	}
+	if meta != nil {
+		h.cache.Set(cacheKey, meta)
+	}
+	return meta, nil
+}
+
+// loadOriginal returns the original text of a paste from the cache or S3.
+func (h *PasteHandler) loadOriginal(ctx context.Context, meta *models.PasteMeta) (string, error) {
+	cacheKey := fmt.Sprintf("%s:%s", meta.PasteID, meta.OriginalLanguage)
+	if cached, ok := h.cache.Get(cacheKey); ok {
+		return cached.(string), nil
 	}
 
-	original, err := h.storage.GetOriginal(ctx, pasteID)
+	original, err := h.storage.GetOriginal(ctx, meta.PasteID)
 	if err != nil {
-		log.Printf("Error getting original from S3: %v", err)
-		http.Error(w, "Failed to load paste", http.StatusInternalServerError)
-		return
+		return "", err
+	}
+	h.cache.Set(cacheKey, original)
+	return original, nil
+}
+
+// loadTranslation returns a stored translation variant from the cache or S3.
+func (h *PasteHandler) loadTranslation(ctx context.Context, pasteID, variantKey string) (string, bool) {
+	cacheKey := fmt.Sprintf("%s:%s", pasteID, variantKey)
+	if cached, ok := h.cache.Get(cacheKey); ok {
+		return cached.(string), true
 	}
 
-	// Perform translation
-	translation, err = h.translator.Translate(ctx, original, targetLang, meta.Tone)
+	translation, err := h.storage.GetTranslation(ctx, pasteID, variantKey)
 	if err != nil {
-		log.Printf("Error translating: %v", err)
-		http.Error(w, "Translation failed", http.StatusInternalServerError)
-		return
+		return "", false
 	}
+	h.cache.Set(cacheKey, translation)
+	return translation, true
+}
 
-	// Save translation to S3
-	if err := h.storage.SaveTranslation(ctx, pasteID, targetLang, translation); err != nil {
-		log.Printf("Error saving translation to S3: %v", err)
-		// Continue anyway - we have the translation
+// translateOptions builds the translation options for a paste variant,
+// applying the creator's glossary and custom tones when they have them.
+func (h *PasteHandler) translateOptions(ctx context.Context, meta *models.PasteMeta, variant models.TranslationVariant, original string) translate.Options {
+	opts := translate.Options{
+		SourceLanguage:        meta.OriginalLanguage,
+		TargetLanguage:        variant.Language,
+		Tone:                  variant.Tone,
+		Formality:             variant.Formality,
+		TranslateCodeComments: meta.TranslateCodeComments,
+		Format:                meta.Format,
 	}
 
-	// Update metadata to include new language
-	if err := h.db.AddTranslationLanguage(ctx, pasteID, targetLang); err != nil {
-		log.Printf("Error updating paste metadata: %v", err)
+	if creator := cachedAccount(ctx, h.db, h.cache, meta.CreatorAccountID); creator != nil {
+		opts.Glossary = translate.GlossaryTermsFor(creator.Glossary, variant.Language, original)
+		if creator.Glossary != nil {
+			opts.GlossaryVersion = fmt.Sprintf("%s@%d", creator.AccountID, creator.Glossary.Version)
+		}
+		if !translate.IsBuiltinTone(variant.Tone) {
+			opts.CustomTone = translate.FindCustomTone(creator.CustomTones, variant.Tone)
+		}
+	}
+	if opts.CustomTone == nil && !translate.IsBuiltinTone(variant.Tone) {
+		log.Printf("Custom tone %q of paste %s no longer exists, using default", variant.Tone, meta.PasteID)
 	}
 
-	// Cache the translation
-	h.cache.Set(cacheKey, translation)
+	return opts
+}
 
-	resp := models.TranslateResponse{
-		Language:    targetLang,
-		Translation: translation,
+// checkGlossary verifies a fresh translation against the glossary terms it
+// was produced with and logs any violations.
+func (h *PasteHandler) checkGlossary(pasteID, variantKey, translation string, opts translate.Options) []models.GlossaryViolation {
+	violations := translate.CheckGlossary(translation, opts.Glossary)
+	if len(violations) > 0 {
+		log.Printf("Glossary violations in paste %s (%s): %d", pasteID, variantKey, len(violations))
 	}
+	return violations
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+// checkPlaceholders compares the placeholders of a fresh translation with
+// those of the original and logs any mismatches.
+func (h *PasteHandler) checkPlaceholders(pasteID, variantKey, original, translation string) []models.PlaceholderMismatch {
+	mismatches := translate.CheckPlaceholders(original, translation)
+	if len(mismatches) > 0 {
+		log.Printf("Placeholder mismatches in paste %s (%s): %d", pasteID, variantKey, len(mismatches))
+	}
+	return mismatches
+}
+
+// languageTagError describes why a requested language was rejected.
+func languageTagError(err error) string {
+	if errors.Is(err, translate.ErrUnsupportedLanguage) {
+		return "Unsupported language"
+	}
+	return "Invalid language tag"
+}
+
+// formalityError describes why a requested formality was rejected.
+func formalityError(err error) string {
+	if errors.Is(err, translate.ErrUnsupportedFormality) {
+		return "Formality is not supported for this language"
+	}
+	return "Invalid formality. Must be: informal, formal or honorific"
+}
+
+// maxLengthFor returns the paste length limit for the given account. Paid
+// accounts get a higher limit since long pastes are translated in segments.
+func (h *PasteHandler) maxLengthFor(ctx context.Context, accountID string) int {
+	if accountID == "" {
+		return h.maxLength
+	}
+
+	account, err := h.db.GetAccountByID(ctx, accountID)
+	if err != nil {
+		log.Printf("Error getting account: %v", err)
+		return h.maxLength
+	}
+	if account != nil && account.IsPaid {
+		return h.maxPaidLength
+	}
+	return h.maxLength
+}
+
+// tierFor returns the tier whose models serve the given account.
+func (h *PasteHandler) tierFor(ctx context.Context, accountID string) string {
+	if account := cachedAccount(ctx, h.db, h.cache, accountID); account != nil && account.IsPaid {
+		return translate.TierPaid
+	}
+	return translate.TierFree
+}
+
+// freshTranslation is a translation produced by the current request.
+type freshTranslation struct {
+	variant     models.TranslationVariant
+	translation string
+	usage       models.Usage
+}
+
+// storeTranslations persists freshly produced translations to S3, records
+// them and their usage on the paste metadata in a single write and caches
+// them. Failures are logged but not returned since the caller already has
+// the translations in hand.
+func (h *PasteHandler) storeTranslations(ctx context.Context, pasteID string, fresh []freshTranslation) {
+	stored := make([]db.StoredTranslation, 0, len(fresh))
+	for _, f := range fresh {
+		// Save translation to S3
+		if err := h.storage.SaveTranslation(ctx, pasteID, f.variant.Key, f.translation); err != nil {
+			log.Printf("Error saving translation to S3: %v", err)
+			// Continue anyway - we have the translation
+		}
+
+		entry := db.StoredTranslation{VariantKey: f.variant.Key}
+		if f.variant.Key == f.variant.Language {
+			entry = db.StoredTranslation{Language: f.variant.Language}
+		}
+		if f.usage.Calls > 0 || f.usage.MemoryHits > 0 {
+			usage := f.usage
+			entry.Usage = &usage
+		}
+		stored = append(stored, entry)
+
+		h.cache.Set(fmt.Sprintf("%s:%s", pasteID, f.variant.Key), f.translation)
+
+		// A romanization of the translation this one replaces is stale.
+		if _, ok := translate.Romanization(f.variant.Language); ok {
+			h.cache.Delete(romanizationCacheKey(pasteID, f.variant.Key))
+			if err := h.storage.DeleteRomanization(ctx, pasteID, f.variant.Key); err != nil {
+				log.Printf("Error deleting stale romanization from S3: %v", err)
+			}
+		}
+	}
+
+	// Update metadata to include the new translations
+	if err := h.db.AddTranslations(ctx, pasteID, stored); err != nil {
+		log.Printf("Error updating paste metadata: %v", err)
+	}
+
+	// Drop the now stale metadata
+	h.cache.Delete(fmt.Sprintf("meta:%s", pasteID))
 }
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lingopaste/backend/internal/models"
+	"github.com/lingopaste/backend/internal/translate"
+)
+
+// pretranslateTimeout bounds the background translations of a new paste.
+// Languages still pending after it (plus some slack for a server that went
+// away mid-way) are reported as failed.
+const pretranslateTimeout = 10 * time.Minute
+
+// pretranslate produces the translations requested when the paste was
+// created and records each language as ready or failed.
+func (h *PasteHandler) pretranslate(ctx context.Context, meta *models.PasteMeta, languages []string) {
+	translateCtx, cancel := context.WithTimeout(ctx, pretranslateTimeout)
+	defer cancel()
+
+	variants := make(map[string]models.TranslationVariant, len(languages))
+	for _, language := range languages {
+		variants[language] = h.variantFor(meta, language, meta.Tone, "")
+	}
+
+	var fresh []freshTranslation
+	failed := make(map[string]string)
+	for language, outcome := range h.translateAll(translateCtx, meta, variants) {
+		if outcome.err != nil {
+			log.Printf("Error pre-translating paste %s into %s: %v", meta.PasteID, language, outcome.err)
+			failed[language] = models.TranslationFailed
+			continue
+		}
+		if outcome.fresh != nil {
+			fresh = append(fresh, *outcome.fresh)
+		}
+	}
+
+	if len(fresh) > 0 {
+		h.storeTranslations(ctx, meta.PasteID, fresh)
+	}
+	if err := h.db.SetTranslationStatus(ctx, meta.PasteID, failed); err != nil {
+		log.Printf("Error updating translation status: %v", err)
+	}
+	h.cache.Delete(fmt.Sprintf("meta:%s", meta.PasteID))
+}
+
+// translationStatus reports every available translation as ready alongside
+// the status of languages requested at creation time.
+func translationStatus(meta *models.PasteMeta) map[string]string {
+	status := make(map[string]string, len(meta.AvailableTranslations)+len(meta.TranslationStatus))
+
+	stale := time.Since(time.Unix(meta.CreatedAt, 0)) > 2*pretranslateTimeout
+	for language, s := range meta.TranslationStatus {
+		if s == models.TranslationPending && stale {
+			s = models.TranslationFailed
+		}
+		status[language] = s
+	}
+	for _, language := range meta.AvailableTranslations {
+		status[language] = models.TranslationReady
+	}
+
+	return status
+}
+
+// normalizeTargetLanguages normalizes and deduplicates the languages
+// requested at creation time.
+func normalizeTargetLanguages(requested []string) ([]string, error) {
+	var languages []string
+	seen := make(map[string]bool, len(requested))
+	for _, language := range uniqueLanguages(requested) {
+		tag, err := translate.NormalizeTag(language)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			languages = append(languages, tag)
+		}
+	}
+	return languages, nil
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lingopaste/backend/internal/models"
+	"github.com/lingopaste/backend/internal/translate"
+)
+
+// Raw serves a paste as a file download with the extension and MIME type
+// of its format, so subtitles come back as .srt or .vtt files. Without
+// "lang" it serves the original; otherwise the stored translation selected
+// like Translate by "lang", "tone" and "formality".
+func (h *PasteHandler) Raw(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var (
+		meta    *models.PasteMeta
+		variant models.TranslationVariant
+	)
+	if r.URL.Query().Get("lang") == "" {
+		pasteID := mux.Vars(r)["id"]
+		if pasteID == "" {
+			http.Error(w, "Paste ID is required", http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		meta, err = h.getMeta(ctx, pasteID)
+		if err != nil {
+			log.Printf("Error getting paste metadata: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if meta == nil {
+			http.Error(w, "Paste not found", http.StatusNotFound)
+			return
+		}
+		variant = h.variantFor(meta, meta.OriginalLanguage, meta.Tone, "")
+	} else {
+		var ok bool
+		if meta, variant, ok = h.parseTranslateRequest(w, r); !ok {
+			return
+		}
+	}
+
+	name := meta.PasteID
+	var text string
+	if variant.Key == meta.OriginalLanguage {
+		var err error
+		if text, err = h.loadOriginal(ctx, meta); err != nil {
+			log.Printf("Error getting original from S3: %v", err)
+			http.Error(w, "Failed to load paste", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		var ok bool
+		if text, ok = h.loadTranslation(ctx, meta.PasteID, variant.Key); !ok {
+			http.Error(w, "Translation not found", http.StatusNotFound)
+			return
+		}
+		name += "." + variant.Key
+	}
+
+	extension, mimeType := translate.FileType(meta.Format)
+	w.Header().Set("Content-Type", mimeType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + extension}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write([]byte(text))
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lingopaste/backend/internal/db"
+	"github.com/lingopaste/backend/internal/models"
+	"github.com/lingopaste/backend/internal/translate"
+)
+
+// maxRetranslatePastes bounds how many of an account's most recent pastes
+// are re-translated after an upgrade.
+const maxRetranslatePastes = 100
+
+// retranslateTimeout bounds the re-translation of each paste.
+const retranslateTimeout = 10 * time.Minute
+
+// retranslateLease is how long a re-translation holds the account's claim
+// without renewing it. It is renewed before each paste.
+const retranslateLease = retranslateTimeout + time.Minute
+
+// Retranslate re-translates the stored translations of the caller's pastes
+// that were made with free tier models, once the account is paid. It is
+// meant to be called after an upgrade; the work happens in the background,
+// and only one re-translation per account runs at a time.
+func (h *PasteHandler) Retranslate(w http.ResponseWriter, r *http.Request) {
+	account, ok := requireAccount(w, r, h.db)
+	if !ok {
+		return
+	}
+	if !account.IsPaid {
+		http.Error(w, "Re-translation requires a paid account", http.StatusPaymentRequired)
+		return
+	}
+
+	ctx := r.Context()
+
+	// The cached account may predate the upgrade.
+	h.cache.Delete(accountCacheKey(account.AccountID))
+
+	pastes, err := h.db.ListPastesByCreator(ctx, account.AccountID, maxRetranslatePastes)
+	if err != nil {
+		log.Printf("Error listing pastes: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var resp models.RetranslateResponse
+	var queued []retranslation
+	for i := range pastes {
+		variants := freeTierVariants(&pastes[i])
+		if len(variants) == 0 {
+			continue
+		}
+		queued = append(queued, retranslation{&pastes[i], variants})
+		resp.Pastes++
+		resp.Translations += len(variants)
+	}
+	if len(queued) > 0 {
+		err := h.db.ClaimRetranslation(ctx, account, time.Now().Add(retranslateLease))
+		if errors.Is(err, db.ErrRetranslationRunning) {
+			http.Error(w, "A re-translation is already running for this account", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Printf("Error claiming re-translation: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		go h.retranslate(context.WithoutCancel(ctx), account, queued)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// freeTierVariants returns the stored translations of a paste that were not
+// made for the paid tier, including those from before tiers were recorded.
+func freeTierVariants(meta *models.PasteMeta) []models.TranslationVariant {
+	var variants []models.TranslationVariant
+	for _, language := range meta.AvailableTranslations {
+		if language != meta.OriginalLanguage && meta.TranslationUsage[language].Tier != translate.TierPaid {
+			variants = append(variants, models.NewTranslationVariant(language, meta.Tone, meta.Formality, meta.Tone, meta.Formality))
+		}
+	}
+	for _, key := range meta.TranslationVariants {
+		if meta.TranslationUsage[key].Tier != translate.TierPaid {
+			variants = append(variants, models.ParseVariantKey(key, meta.Tone, meta.Formality))
+		}
+	}
+	return variants
+}
+
+// retranslation is a paste and the variants of it to translate again.
+type retranslation struct {
+	meta     *models.PasteMeta
+	variants []models.TranslationVariant
+}
+
+// retranslate works through the queued pastes one at a time so an upgrade
+// does not flood the provider, holding the account's claim until done.
+func (h *PasteHandler) retranslate(ctx context.Context, account *models.Account, queued []retranslation) {
+	defer func() {
+		if err := h.db.ReleaseRetranslation(ctx, account); err != nil {
+			log.Printf("Error releasing re-translation of account %s: %v", account.AccountID, err)
+		}
+	}()
+	for _, q := range queued {
+		if err := h.db.RenewRetranslation(ctx, account, time.Now().Add(retranslateLease)); err != nil {
+			log.Printf("Error renewing re-translation of account %s: %v", account.AccountID, err)
+		}
+		h.retranslatePaste(ctx, q.meta, q.variants)
+	}
+}
+
+// retranslatePaste replaces the given translations of a paste with fresh
+// ones, keeping the stored translation of any variant that fails.
+func (h *PasteHandler) retranslatePaste(ctx context.Context, meta *models.PasteMeta, variants []models.TranslationVariant) {
+	translateCtx, cancel := context.WithTimeout(ctx, retranslateTimeout)
+	defer cancel()
+
+	original := sync.OnceValues(func() (string, error) {
+		return h.storage.GetOriginal(translateCtx, meta.PasteID)
+	})
+
+	var fresh []freshTranslation
+	for _, variant := range variants {
+		_, translation, err := h.produceTranslation(translateCtx, meta, variant, original)
+		if err != nil {
+			log.Printf("Error re-translating paste %s into %s: %v", meta.PasteID, variant.Key, err)
+			continue
+		}
+		fresh = append(fresh, *translation)
+	}
+
+	if len(fresh) > 0 {
+		h.storeTranslations(ctx, meta.PasteID, fresh)
+	}
+	h.cache.Delete(fmt.Sprintf("meta:%s", meta.PasteID))
+}
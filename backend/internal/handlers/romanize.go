@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/lingopaste/backend/internal/models"
+	"github.com/lingopaste/backend/internal/translate"
+)
+
+// wantsRomanization reports whether the request asks for romanized
+// renderings with "romanized=true".
+func wantsRomanization(r *http.Request) bool {
+	romanized, _ := strconv.ParseBool(r.URL.Query().Get("romanized"))
+	return romanized
+}
+
+func romanizationCacheKey(pasteID, variantKey string) string {
+	return fmt.Sprintf("%s:%s:romanized", pasteID, variantKey)
+}
+
+// romanize returns the romanization of a stored translation, producing and
+// storing it next to the translation on first request.
+func (h *PasteHandler) romanize(ctx context.Context, meta *models.PasteMeta, variant models.TranslationVariant, translation string) (string, error) {
+	cacheKey := romanizationCacheKey(meta.PasteID, variant.Key)
+	if cached, ok := h.cache.Get(cacheKey); ok {
+		return cached.(string), nil
+	}
+	if romanized, err := h.storage.GetRomanization(ctx, meta.PasteID, variant.Key); err == nil {
+		h.cache.Set(cacheKey, romanized)
+		return romanized, nil
+	}
+
+	opts := translate.Options{
+		SourceLanguage:        variant.Language,
+		TargetLanguage:        variant.Language,
+		TranslateCodeComments: meta.TranslateCodeComments,
+		Format:                meta.Format,
+		Romanize:              true,
+	}
+	tier := h.tierFor(ctx, meta.CreatorAccountID)
+	meterCtx, meter := translate.WithMeter(translate.WithTier(ctx, tier))
+	romanized, err := h.translator.Translate(meterCtx, translation, opts)
+	usage := meter.Usage()
+	usage.Tier = tier
+	recordUsage(ctx, h.db, usage)
+	if err != nil {
+		return "", err
+	}
+
+	if err := h.storage.SaveRomanization(ctx, meta.PasteID, variant.Key, romanized); err != nil {
+		log.Printf("Error saving romanization to S3: %v", err)
+	}
+	h.cache.Set(cacheKey, romanized)
+	return romanized, nil
+}
+
+// romanizeAll romanizes the given translations, keyed by variant key, that
+// are in languages with a romanization, with at most batchLimit in flight.
+// Failures are logged and left out.
+func (h *PasteHandler) romanizeAll(ctx context.Context, meta *models.PasteMeta, translations map[string]string) map[string]string {
+	romanizations := make(map[string]string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, h.batchLimit)
+
+	for key, translation := range translations {
+		variant := models.ParseVariantKey(key, meta.Tone, meta.Formality)
+		if _, ok := translate.Romanization(variant.Language); !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			romanized, err := h.romanize(ctx, meta, variant, translation)
+			if err != nil {
+				log.Printf("Error romanizing paste %s (%s): %v", meta.PasteID, key, err)
+				return
+			}
+			mu.Lock()
+			romanizations[key] = romanized
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return romanizations
+}
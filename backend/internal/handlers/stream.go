@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/lingopaste/backend/internal/models"
+	"github.com/lingopaste/backend/internal/translate"
+)
+
+// streamWriteTimeout bounds a single streamed translation. It replaces the
+// server-wide WriteTimeout, which is too short for long pastes.
+const streamWriteTimeout = 5 * time.Minute
+
+// TranslateStream is the Server-Sent Events variant of Translate. It emits
+// "chunk" events as the provider produces text, followed by a single "done"
+// event carrying the full TranslateResponse, or an "error" event.
+func (h *PasteHandler) TranslateStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	meta, variant, ok := h.parseTranslateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	// Serve an existing translation as a single chunk
+	translation, found := h.loadTranslation(ctx, meta.PasteID, variant.Key)
+
+	var original string
+	var opts translate.Options
+	if !found {
+		var err error
+		original, err = h.storage.GetOriginal(ctx, meta.PasteID)
+		if err != nil {
+			log.Printf("Error getting original from S3: %v", err)
+			http.Error(w, "Failed to load paste", http.StatusInternalServerError)
+			return
+		}
+		opts = h.translateOptions(ctx, meta, variant, original)
+	}
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		log.Printf("Error extending write deadline: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	emitChunk := func(chunk string) error {
+		if err := writeSSE(w, "chunk", map[string]string{"text": chunk}); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	if found {
+		if err := emitChunk(translation); err != nil {
+			return
+		}
+	} else {
+		var err error
+		tier := h.tierFor(ctx, meta.CreatorAccountID)
+		meterCtx, meter := translate.WithMeter(translate.WithTier(ctx, tier))
+		if streamer, ok := h.translator.(translate.StreamingTranslator); ok {
+			translation, err = streamer.TranslateStream(meterCtx, original, opts, emitChunk)
+		} else {
+			translation, err = h.translator.Translate(meterCtx, original, opts)
+			if err == nil {
+				err = emitChunk(translation)
+			}
+		}
+		usage := meter.Usage()
+		usage.Tier = tier
+		recordUsage(ctx, h.db, usage)
+		if err != nil {
+			log.Printf("Error streaming translation: %v", err)
+			if retryAfter, ok := unavailable(err); ok {
+				writeSSE(w, "error", map[string]interface{}{
+					"error":       "Translation service temporarily unavailable",
+					"retry_after": retryAfter,
+				})
+			} else {
+				writeSSE(w, "error", map[string]string{"error": "Translation failed"})
+			}
+			flusher.Flush()
+			return
+		}
+
+		h.storeTranslations(ctx, meta.PasteID, []freshTranslation{{variant, translation, usage}})
+	}
+
+	resp := models.TranslateResponse{
+		Language:    variant.Language,
+		Tone:        variant.Tone,
+		Formality:   variant.Formality,
+		Translation: translation,
+	}
+	if !found {
+		resp.GlossaryViolations = h.checkGlossary(meta.PasteID, variant.Key, translation, opts)
+		resp.PlaceholderMismatches = h.checkPlaceholders(meta.PasteID, variant.Key, original, translation)
+	}
+	writeSSE(w, "done", resp)
+	flusher.Flush()
+}
+
+// writeSSE writes a single Server-Sent Event with a JSON-encoded payload.
+func writeSSE(w http.ResponseWriter, event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
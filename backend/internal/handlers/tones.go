@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lingopaste/backend/internal/cache"
+	"github.com/lingopaste/backend/internal/db"
+	"github.com/lingopaste/backend/internal/models"
+	"github.com/lingopaste/backend/internal/translate"
+)
+
+const (
+	maxCustomTones        = 20
+	maxToneInstructionLen = 1000
+	maxToneExamples       = 5
+	maxToneExampleLen     = 300
+	maxToneTemperature    = 2.0
+)
+
+// ToneHandler manages the custom tones of the authenticated account.
+type ToneHandler struct {
+	db    *db.DynamoDB
+	cache *cache.LRUCache
+}
+
+func NewToneHandler(db *db.DynamoDB, cache *cache.LRUCache) *ToneHandler {
+	return &ToneHandler{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// List returns the built-in tones and the account's custom tones.
+func (h *ToneHandler) List(w http.ResponseWriter, r *http.Request) {
+	account, ok := requireAccount(w, r, h.db)
+	if !ok {
+		return
+	}
+
+	custom := account.CustomTones
+	if custom == nil {
+		custom = []models.CustomTone{}
+	}
+
+	resp := models.ListTonesResponse{
+		Builtin: translate.BuiltinTones(),
+		Custom:  custom,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Put creates or replaces the custom tone named in the URL.
+func (h *ToneHandler) Put(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateCustomToneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tone := models.CustomTone{
+		Name:        mux.Vars(r)["name"],
+		Instruction: strings.TrimSpace(req.Instruction),
+		Temperature: req.Temperature,
+		Examples:    req.Examples,
+	}
+	if err := validateCustomTone(&tone); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	account, ok := requireAccount(w, r, h.db)
+	if !ok {
+		return
+	}
+
+	replaced := false
+	tones := make([]models.CustomTone, 0, len(account.CustomTones)+1)
+	for _, existing := range account.CustomTones {
+		if existing.Name == tone.Name {
+			tones = append(tones, tone)
+			replaced = true
+			continue
+		}
+		tones = append(tones, existing)
+	}
+	if !replaced {
+		if len(tones) >= maxCustomTones {
+			http.Error(w, fmt.Sprintf("Accounts cannot have more than %d custom tones", maxCustomTones), http.StatusBadRequest)
+			return
+		}
+		tones = append(tones, tone)
+	}
+
+	if !h.save(w, r, account, tones) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tone)
+}
+
+func (h *ToneHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	account, ok := requireAccount(w, r, h.db)
+	if !ok {
+		return
+	}
+
+	tones := make([]models.CustomTone, 0, len(account.CustomTones))
+	for _, existing := range account.CustomTones {
+		if existing.Name != name {
+			tones = append(tones, existing)
+		}
+	}
+	if len(tones) == len(account.CustomTones) {
+		http.Error(w, "Custom tone not found", http.StatusNotFound)
+		return
+	}
+
+	if !h.save(w, r, account, tones) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ToneHandler) save(w http.ResponseWriter, r *http.Request, account *models.Account, tones []models.CustomTone) bool {
+	if err := h.db.SaveCustomTones(r.Context(), account, tones); err != nil {
+		log.Printf("Error saving custom tones: %v", err)
+		http.Error(w, "Failed to save custom tones", http.StatusInternalServerError)
+		return false
+	}
+
+	h.cache.Delete(accountCacheKey(account.AccountID))
+	return true
+}
+
+// validateCustomTone checks a tone definition and trims its examples in place.
+func validateCustomTone(tone *models.CustomTone) error {
+	if !translate.IsValidToneName(tone.Name) {
+		return fmt.Errorf("tone names must be 1-40 lowercase letters, digits or dashes and not a built-in tone")
+	}
+	if tone.Instruction == "" {
+		return fmt.Errorf("tone instruction is required")
+	}
+	if len(tone.Instruction) > maxToneInstructionLen {
+		return fmt.Errorf("tone instruction exceeds %d characters", maxToneInstructionLen)
+	}
+	if tone.Temperature != nil && (*tone.Temperature < 0 || *tone.Temperature > maxToneTemperature) {
+		return fmt.Errorf("tone temperature must be between 0 and %.1f", maxToneTemperature)
+	}
+	if len(tone.Examples) > maxToneExamples {
+		return fmt.Errorf("tones cannot have more than %d examples", maxToneExamples)
+	}
+
+	examples := make([]string, 0, len(tone.Examples))
+	for _, example := range tone.Examples {
+		example = strings.TrimSpace(example)
+		if example == "" {
+			continue
+		}
+		if len(example) > maxToneExampleLen {
+			return fmt.Errorf("tone examples cannot exceed %d characters", maxToneExampleLen)
+		}
+		examples = append(examples, example)
+	}
+	tone.Examples = examples
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/lingopaste/backend/internal/db"
+	"github.com/lingopaste/backend/internal/middleware"
+	"github.com/lingopaste/backend/internal/models"
+	"github.com/lingopaste/backend/internal/utils"
+)
+
+// recordUsage adds the provider usage of the current request to today's
+// totals of the caller's IP hash and, when signed in, their account.
+// Failed requests are recorded too since their calls were billed all the
+// same.
+func recordUsage(ctx context.Context, database *db.DynamoDB, usage models.Usage) {
+	if usage.Calls == 0 {
+		return
+	}
+
+	// The client may already be gone; the spend still has to be counted.
+	ctx = context.WithoutCancel(ctx)
+	date := time.Now().UTC().Format("2006-01-02")
+
+	ipHash := utils.HashIP(middleware.GetIPFromContext(ctx))
+	if err := database.AddUsage(ctx, db.UsageIP, ipHash, date, usage); err != nil {
+		log.Printf("Error recording IP usage: %v", err)
+	}
+
+	if accountID := middleware.GetAccountIDFromContext(ctx); accountID != "" {
+		if err := database.AddUsage(ctx, db.UsageAccount, accountID, date, usage); err != nil {
+			log.Printf("Error recording account usage: %v", err)
+		}
+	}
+}
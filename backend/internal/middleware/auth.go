@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const AccountIDContextKey contextKey = "account_id"
+
+// maxClockSkew is how many seconds the nbf and iat claims of a token may be
+// ahead of the server's clock.
+const maxClockSkew = 60
+
+// Authenticator verifies HS256-signed JWTs from the Authorization header and
+// stores the account ID (the "sub" claim) in the request context. Requests
+// without a token pass through anonymously.
+type Authenticator struct {
+	secret []byte
+}
+
+func NewAuthenticator(secret string) *Authenticator {
+	return &Authenticator{secret: []byte(secret)}
+}
+
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			http.Error(w, "Invalid authorization header", http.StatusUnauthorized)
+			return
+		}
+
+		accountID, err := a.verify(token)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), AccountIDContextKey, accountID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (a *Authenticator) verify(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", err
+	}
+	if header.Alg != "HS256" {
+		return "", fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed signature: %w", err)
+	}
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", fmt.Errorf("invalid signature")
+	}
+
+	var claims struct {
+		Subject   string `json:"sub"`
+		ExpiresAt *int64 `json:"exp"`
+		NotBefore *int64 `json:"nbf"`
+		IssuedAt  *int64 `json:"iat"`
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", err
+	}
+	// Tokens must expire, so a leaked one is not valid forever. The
+	// optional time claims allow for clock skew between issuer and server.
+	now := time.Now().Unix()
+	if claims.ExpiresAt == nil {
+		return "", fmt.Errorf("missing expiry")
+	}
+	if now >= *claims.ExpiresAt {
+		return "", fmt.Errorf("token expired")
+	}
+	if claims.NotBefore != nil && now+maxClockSkew < *claims.NotBefore {
+		return "", fmt.Errorf("token not yet valid")
+	}
+	if claims.IssuedAt != nil && now+maxClockSkew < *claims.IssuedAt {
+		return "", fmt.Errorf("token issued in the future")
+	}
+	if claims.Subject == "" {
+		return "", fmt.Errorf("missing subject")
+	}
+
+	return claims.Subject, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("malformed token segment: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("malformed token segment: %w", err)
+	}
+	return nil
+}
+
+// GetAccountIDFromContext returns the authenticated account ID, or "" for
+// anonymous requests.
+func GetAccountIDFromContext(ctx context.Context) string {
+	if accountID, ok := ctx.Value(AccountIDContextKey).(string); ok {
+		return accountID
+	}
+	return ""
+}
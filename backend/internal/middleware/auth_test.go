@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+func signToken(t *testing.T, secret string, header, claims map[string]interface{}) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("failed to marshal token segment: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	unsigned := encode(header) + "." + encode(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now().Unix()
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"valid", signToken(t, testSecret, hs256, map[string]interface{}{"sub": "acct", "exp": now + 3600}), false},
+		{"valid with nbf and iat", signToken(t, testSecret, hs256, map[string]interface{}{"sub": "acct", "exp": now + 3600, "nbf": now, "iat": now}), false},
+		{"iat within clock skew", signToken(t, testSecret, hs256, map[string]interface{}{"sub": "acct", "exp": now + 3600, "iat": now + 30}), false},
+		{"missing exp", signToken(t, testSecret, hs256, map[string]interface{}{"sub": "acct"}), true},
+		{"expired", signToken(t, testSecret, hs256, map[string]interface{}{"sub": "acct", "exp": now - 1}), true},
+		{"not yet valid", signToken(t, testSecret, hs256, map[string]interface{}{"sub": "acct", "exp": now + 3600, "nbf": now + 600}), true},
+		{"issued in the future", signToken(t, testSecret, hs256, map[string]interface{}{"sub": "acct", "exp": now + 3600, "iat": now + 600}), true},
+		{"missing subject", signToken(t, testSecret, hs256, map[string]interface{}{"exp": now + 3600}), true},
+		{"wrong secret", signToken(t, "other-secret", hs256, map[string]interface{}{"sub": "acct", "exp": now + 3600}), true},
+		{"alg none", signToken(t, testSecret, map[string]interface{}{"alg": "none"}, map[string]interface{}{"sub": "acct", "exp": now + 3600}), true},
+		{"alg HS512", signToken(t, testSecret, map[string]interface{}{"alg": "HS512"}, map[string]interface{}{"sub": "acct", "exp": now + 3600}), true},
+		{"malformed", "not-a-token", true},
+		{"bad signature encoding", "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJhY2N0In0.!!!", true},
+	}
+
+	a := NewAuthenticator(testSecret)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountID, err := a.verify(tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("verify() = %q, want error", accountID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verify() error = %v", err)
+			}
+			if accountID != "acct" {
+				t.Errorf("verify() = %q, want %q", accountID, "acct")
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	a := NewAuthenticator(testSecret)
+	var got string
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = GetAccountIDFromContext(r.Context())
+	}))
+
+	valid := signToken(t, testSecret, map[string]interface{}{"alg": "HS256"}, map[string]interface{}{"sub": "acct", "exp": time.Now().Unix() + 3600})
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantAccount   string
+	}{
+		{"anonymous", "", http.StatusOK, ""},
+		{"valid token", "Bearer " + valid, http.StatusOK, "acct"},
+		{"not bearer", "Basic abc", http.StatusUnauthorized, ""},
+		{"invalid token", "Bearer abc.def.ghi", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = ""
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got != tt.wantAccount {
+				t.Errorf("account = %q, want %q", got, tt.wantAccount)
+			}
+		})
+	}
+}
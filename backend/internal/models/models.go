@@ -1,25 +1,115 @@
 package models
 
 type Account struct {
-	AccountID            string `json:"account_id" dynamodbav:"account_id"`
-	Email                string `json:"email" dynamodbav:"email"`
-	AuthProvider         string `json:"auth_provider" dynamodbav:"auth_provider"`
-	IsPaid               bool   `json:"is_paid" dynamodbav:"is_paid"`
-	StripeCustomerID     string `json:"stripe_customer_id,omitempty" dynamodbav:"stripe_customer_id,omitempty"`
-	StripeSubscriptionID string `json:"stripe_subscription_id,omitempty" dynamodbav:"stripe_subscription_id,omitempty"`
-	CreatedAt            int64  `json:"created_at" dynamodbav:"created_at"`
-	UpdatedAt            int64  `json:"updated_at" dynamodbav:"updated_at"`
+	AccountID            string       `json:"account_id" dynamodbav:"account_id"`
+	Email                string       `json:"email" dynamodbav:"email"`
+	AuthProvider         string       `json:"auth_provider" dynamodbav:"auth_provider"`
+	IsPaid               bool         `json:"is_paid" dynamodbav:"is_paid"`
+	StripeCustomerID     string       `json:"stripe_customer_id,omitempty" dynamodbav:"stripe_customer_id,omitempty"`
+	StripeSubscriptionID string       `json:"stripe_subscription_id,omitempty" dynamodbav:"stripe_subscription_id,omitempty"`
+	Glossary             *Glossary    `json:"glossary,omitempty" dynamodbav:"glossary,omitempty"`
+	CustomTones          []CustomTone `json:"custom_tones,omitempty" dynamodbav:"custom_tones,omitempty"`
+	CreatedAt            int64        `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt            int64        `json:"updated_at" dynamodbav:"updated_at"`
+
+	// RetranslatingUntil is when the lease of a running re-translation of
+	// the account's pastes runs out.
+	RetranslatingUntil int64 `json:"-" dynamodbav:"retranslating_until,omitempty"`
+}
+
+// Glossary fixes how an account's product names and domain terms are
+// translated. Version is bumped on every change.
+type Glossary struct {
+	Version   int             `json:"version" dynamodbav:"version"`
+	Entries   []GlossaryEntry `json:"entries" dynamodbav:"entries"`
+	UpdatedAt int64           `json:"updated_at" dynamodbav:"updated_at"`
+}
+
+// GlossaryEntry maps a source term to its rendering per target language, or
+// marks it as one that must never be translated.
+type GlossaryEntry struct {
+	Term           string            `json:"term" dynamodbav:"term"`
+	DoNotTranslate bool              `json:"do_not_translate,omitempty" dynamodbav:"do_not_translate,omitempty"`
+	Translations   map[string]string `json:"translations,omitempty" dynamodbav:"translations,omitempty"`
+}
+
+// CustomTone is an account-defined tone selectable by name when creating a
+// paste. Temperature overrides the default sampling temperature when set.
+type CustomTone struct {
+	Name        string   `json:"name" dynamodbav:"name"`
+	Instruction string   `json:"instruction" dynamodbav:"instruction"`
+	Temperature *float32 `json:"temperature,omitempty" dynamodbav:"temperature,omitempty"`
+	Examples    []string `json:"examples,omitempty" dynamodbav:"examples,omitempty"`
+}
+
+type GlossaryViolation struct {
+	Term     string `json:"term"`
+	Expected string `json:"expected"`
+}
+
+// PlaceholderMismatch is a placeholder that a translation has a different
+// number of times than its source.
+type PlaceholderMismatch struct {
+	Placeholder string `json:"placeholder"`
+	Expected    int    `json:"expected"`
+	Found       int    `json:"found"`
 }
 
 type PasteMeta struct {
 	PasteID               string   `json:"paste_id" dynamodbav:"paste_id"`
 	OriginalLanguage      string   `json:"original_language" dynamodbav:"original_language"`
 	Tone                  string   `json:"tone" dynamodbav:"tone"`
+	Formality             string   `json:"formality,omitempty" dynamodbav:"formality,omitempty"`
+	TranslateCodeComments bool     `json:"translate_code_comments,omitempty" dynamodbav:"translate_code_comments,omitempty"`
 	CreatorIPHash         string   `json:"creator_ip_hash" dynamodbav:"creator_ip_hash"`
 	CreatorAccountID      string   `json:"creator_account_id,omitempty" dynamodbav:"creator_account_id,omitempty"`
 	CreatedAt             int64    `json:"created_at" dynamodbav:"created_at"`
 	CharacterCount        int      `json:"character_count" dynamodbav:"character_count"`
 	AvailableTranslations []string `json:"available_translations" dynamodbav:"available_translations"`
+	// Format is the structured format detected at creation, such as "srt"
+	// or "vtt"; it is empty for plain text.
+	Format string `json:"format,omitempty" dynamodbav:"format,omitempty"`
+	// TranslationVariants lists the keys of translations rendered in a tone
+	// or formality other than the paste's own (see TranslationVariant).
+	TranslationVariants []string `json:"translation_variants,omitempty" dynamodbav:"translation_variants,omitempty"`
+	// TranslationStatus tracks the languages requested at creation time
+	// while they are translated in the background.
+	TranslationStatus map[string]string `json:"translation_status,omitempty" dynamodbav:"translation_status,omitempty"`
+	// DetectionUsage is set when detecting the original language needed the
+	// provider; TranslationUsage is keyed by variant key.
+	DetectionUsage   *Usage           `json:"detection_usage,omitempty" dynamodbav:"detection_usage,omitempty"`
+	TranslationUsage map[string]Usage `json:"translation_usage,omitempty" dynamodbav:"translation_usage,omitempty"`
+}
+
+// Usage is the provider consumption of one or more calls. CostUSD is an
+// estimate from list prices; Estimated marks token counts that were
+// approximated locally because the provider did not report them. Backend
+// names the translator backends that produced the result.
+type Usage struct {
+	Backend string `json:"backend,omitempty" dynamodbav:"backend,omitempty"`
+	Model   string `json:"model" dynamodbav:"model"`
+	// Tier is the account tier the models were chosen for.
+	Tier             string  `json:"tier,omitempty" dynamodbav:"tier,omitempty"`
+	Calls            int     `json:"calls" dynamodbav:"calls"`
+	PromptTokens     int     `json:"prompt_tokens" dynamodbav:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens" dynamodbav:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd" dynamodbav:"cost_usd"`
+	Estimated        bool    `json:"estimated,omitempty" dynamodbav:"estimated,omitempty"`
+	// MemoryHits and MemoryMisses count paragraphs served from and added
+	// to translation memory.
+	MemoryHits   int `json:"memory_hits,omitempty" dynamodbav:"memory_hits,omitempty"`
+	MemoryMisses int `json:"memory_misses,omitempty" dynamodbav:"memory_misses,omitempty"`
+}
+
+// UsageRecord holds one day of provider usage for an account or an IP hash.
+type UsageRecord struct {
+	Identifier       string  `json:"identifier" dynamodbav:"identifier"`
+	Date             string  `json:"date" dynamodbav:"date"`
+	IdentifierType   string  `json:"identifier_type" dynamodbav:"identifier_type"`
+	Calls            int     `json:"calls" dynamodbav:"calls"`
+	PromptTokens     int     `json:"prompt_tokens" dynamodbav:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens" dynamodbav:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd" dynamodbav:"cost_usd"`
 }
 
 type RateLimit struct {
@@ -39,22 +129,48 @@ type Paste struct {
 type CreatePasteRequest struct {
 	Content string `json:"content"`
 	Tone    string `json:"tone"`
+	// Formality selects how translations address the reader in languages
+	// that distinguish it; others ignore it.
+	Formality string `json:"formality"`
+	// TranslateCodeComments translates comments inside fenced code blocks;
+	// by default code is left untouched.
+	TranslateCodeComments bool `json:"translate_code_comments"`
+	// TargetLanguages are translated in the background right after the
+	// paste is created.
+	TargetLanguages []string `json:"target_languages"`
 }
 
+// Statuses of translations requested at creation time.
+const (
+	TranslationPending = "pending"
+	TranslationReady   = "ready"
+	TranslationFailed  = "failed"
+)
+
 type CreatePasteResponse struct {
 	PasteID            string   `json:"paste_id"`
 	OriginalLanguage   string   `json:"original_language"`
 	AvailableLanguages []string `json:"available_languages"`
+	PendingLanguages   []string `json:"pending_languages,omitempty"`
 }
 
 type GetPasteResponse struct {
-	PasteID               string            `json:"paste_id"`
-	OriginalLanguage      string            `json:"original_language"`
-	Tone                  string            `json:"tone"`
-	CreatedAt             int64             `json:"created_at"`
-	Original              string            `json:"original"`
-	Translations          map[string]string `json:"translations"`
-	AvailableTranslations []string          `json:"available_translations"`
+	PasteID               string               `json:"paste_id"`
+	OriginalLanguage      string               `json:"original_language"`
+	Tone                  string               `json:"tone"`
+	Formality             string               `json:"formality,omitempty"`
+	Format                string               `json:"format,omitempty"`
+	CreatedAt             int64                `json:"created_at"`
+	Original              string               `json:"original"`
+	Translations          map[string]string    `json:"translations"`
+	AvailableTranslations []string             `json:"available_translations"`
+	Variants              []TranslationVariant `json:"variants"`
+	// TranslationStatus maps every available or requested language to
+	// pending, ready or failed.
+	TranslationStatus map[string]string `json:"translation_status"`
+	// Romanizations holds the romanized rendering of translations in
+	// non-Latin scripts, when requested.
+	Romanizations map[string]string `json:"romanizations,omitempty"`
 }
 
 type TranslateRequest struct {
@@ -62,6 +178,134 @@ type TranslateRequest struct {
 }
 
 type TranslateResponse struct {
-	Language    string `json:"language"`
-	Translation string `json:"translation"`
+	Language              string                `json:"language"`
+	Tone                  string                `json:"tone"`
+	Formality             string                `json:"formality,omitempty"`
+	Translation           string                `json:"translation"`
+	Romanized             string                `json:"romanized,omitempty"`
+	GlossaryViolations    []GlossaryViolation   `json:"glossary_violations,omitempty"`
+	PlaceholderMismatches []PlaceholderMismatch `json:"placeholder_mismatches,omitempty"`
+}
+
+// BatchTranslateRequest asks for a paste in several languages at once, all
+// in the same tone and formality.
+type BatchTranslateRequest struct {
+	Languages []string `json:"languages"`
+	Tone      string   `json:"tone"`
+	Formality string   `json:"formality"`
+}
+
+// BatchTranslateResponse holds a result or an error for every requested
+// language. Results are keyed by normalized language tag; errors by the
+// language as requested.
+type BatchTranslateResponse struct {
+	Results map[string]TranslateResponse `json:"results"`
+	Errors  map[string]TranslateError    `json:"errors,omitempty"`
+}
+
+type TranslateError struct {
+	Error string `json:"error"`
+	// RetryAfter is set, in seconds, when the provider is temporarily
+	// unavailable.
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+// RetranslateResponse counts the translations queued for re-translation
+// after an account upgrade.
+type RetranslateResponse struct {
+	Pastes       int `json:"pastes"`
+	Translations int `json:"translations"`
+}
+
+// TextSpan is a range of a text in UTF-16 code units, end exclusive.
+type TextSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// AlignedPair links a sentence or paragraph of the original to its
+// rendering in a translation. Either span is empty when the other side has
+// no counterpart.
+type AlignedPair struct {
+	Source TextSpan `json:"source"`
+	Target TextSpan `json:"target"`
+}
+
+// AlignmentResponse pairs up the original and a translation for reading
+// side by side.
+type AlignmentResponse struct {
+	Language    string        `json:"language"`
+	Tone        string        `json:"tone"`
+	Formality   string        `json:"formality,omitempty"`
+	Original    string        `json:"original"`
+	Translation string        `json:"translation"`
+	Pairs       []AlignedPair `json:"pairs"`
+}
+
+type UpdateGlossaryRequest struct {
+	Entries []GlossaryEntry `json:"entries"`
+}
+
+type UpdateGlossaryTermRequest struct {
+	DoNotTranslate bool              `json:"do_not_translate"`
+	Translations   map[string]string `json:"translations"`
+}
+
+// Language describes a supported target language.
+type Language struct {
+	Code         string `json:"code"`
+	Name         string `json:"name"`
+	NativeName   string `json:"native_name"`
+	Direction    string `json:"direction"`
+	SupportsTone bool   `json:"supports_tone"`
+	// FormalityLevels lists the formality options the language
+	// distinguishes; it is empty when formality cannot be requested.
+	FormalityLevels []string `json:"formality_levels,omitempty"`
+	// Romanization names the system used to render the language in Latin
+	// script, if it can be romanized.
+	Romanization string `json:"romanization,omitempty"`
+}
+
+type UpdateCustomToneRequest struct {
+	Instruction string   `json:"instruction"`
+	Temperature *float32 `json:"temperature"`
+	Examples    []string `json:"examples"`
+}
+
+type ListTonesResponse struct {
+	Builtin []string     `json:"builtin"`
+	Custom  []CustomTone `json:"custom"`
+}
+
+type ListLanguagesResponse struct {
+	Languages []Language `json:"languages"`
+}
+
+type SpendTotals struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+type DailySpend struct {
+	Date string `json:"date"`
+	SpendTotals
+}
+
+type SpendEntry struct {
+	Identifier string `json:"identifier"`
+	SpendTotals
+}
+
+// SpendSummaryResponse totals provider spend over a date range. Every call
+// is attributed to the caller's IP hash, so Total sums the IP records;
+// anonymous calls do not appear under TopAccounts.
+type SpendSummaryResponse struct {
+	From        string       `json:"from"`
+	To          string       `json:"to"`
+	Total       SpendTotals  `json:"total"`
+	Days        []DailySpend `json:"days"`
+	TopAccounts []SpendEntry `json:"top_accounts"`
+	TopIPHashes []SpendEntry `json:"top_ip_hashes"`
 }
@@ -0,0 +1,46 @@
+package models
+
+import "strings"
+
+// variantSeparator joins a language tag and a tone override in variant keys;
+// formalitySeparator precedes a formality override.
+const (
+	variantSeparator   = "~"
+	formalitySeparator = "@"
+)
+
+// TranslationVariant identifies one stored rendering of a paste. Key is used
+// in cache and S3 keys: translations in the paste's own tone and formality
+// use the bare language tag, as they always have, while overrides are stored
+// as "{language}~{tone}", "{language}@{formality}" or
+// "{language}~{tone}@{formality}".
+type TranslationVariant struct {
+	Key       string `json:"key"`
+	Language  string `json:"language"`
+	Tone      string `json:"tone"`
+	Formality string `json:"formality,omitempty"`
+}
+
+func NewTranslationVariant(language, tone, formality, pasteTone, pasteFormality string) TranslationVariant {
+	key := language
+	if tone != pasteTone {
+		key += variantSeparator + tone
+	}
+	if formality != pasteFormality {
+		key += formalitySeparator + formality
+	}
+	return TranslationVariant{Key: key, Language: language, Tone: tone, Formality: formality}
+}
+
+// ParseVariantKey is the inverse of NewTranslationVariant.
+func ParseVariantKey(key, pasteTone, pasteFormality string) TranslationVariant {
+	rest, formality, ok := strings.Cut(key, formalitySeparator)
+	if !ok {
+		formality = pasteFormality
+	}
+	language, tone, ok := strings.Cut(rest, variantSeparator)
+	if !ok {
+		tone = pasteTone
+	}
+	return TranslationVariant{Key: key, Language: language, Tone: tone, Formality: formality}
+}
@@ -1,9 +1,11 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -11,6 +13,19 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
+// variantKeyPattern matches translation variant keys: a normalized language
+// tag such as "pt-BR" or "zh-Hant", optionally followed by "~{tone}" and
+// "@{formality}". Anything else is refused before it can become part of a
+// key.
+var variantKeyPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,4})*(~[a-z0-9][a-z0-9-]{0,39})?(@[a-z]{1,20})?$`)
+
+// Suffixes of a translation object and the siblings derived from it.
+const (
+	translationSuffix = ".txt"
+	romanizedSuffix   = ".romanized.txt"
+	alignmentSuffix   = ".alignment.json"
+)
+
 type S3Storage struct {
 	client     *s3.Client
 	bucketName string
@@ -60,32 +75,108 @@ func (s *S3Storage) GetOriginal(ctx context.Context, pasteID string) (string, er
 	return string(body), nil
 }
 
-func (s *S3Storage) SaveTranslation(ctx context.Context, pasteID, language, translation string) error {
-	key := fmt.Sprintf("pastes/%s/translations/%s.txt", pasteID, language)
+func (s *S3Storage) SaveTranslation(ctx context.Context, pasteID, variantKey, translation string) error {
+	key, err := translationKey(pasteID, variantKey, translationSuffix)
+	if err != nil {
+		return err
+	}
+	return s.putText(ctx, key, translation)
+}
+
+func (s *S3Storage) GetTranslation(ctx context.Context, pasteID, variantKey string) (string, error) {
+	key, err := translationKey(pasteID, variantKey, translationSuffix)
+	if err != nil {
+		return "", err
+	}
+	return s.getText(ctx, key)
+}
+
+// SaveRomanization stores the romanized rendering of a translation next to
+// it, as translations/{variant}.romanized.txt.
+func (s *S3Storage) SaveRomanization(ctx context.Context, pasteID, variantKey, romanized string) error {
+	key, err := translationKey(pasteID, variantKey, romanizedSuffix)
+	if err != nil {
+		return err
+	}
+	return s.putText(ctx, key, romanized)
+}
+
+// SaveAlignment stores the JSON-encoded alignment of a translation with
+// the original next to the translation, as
+// translations/{variant}.alignment.json.
+func (s *S3Storage) SaveAlignment(ctx context.Context, pasteID, variantKey string, alignment []byte) error {
+	key, err := translationKey(pasteID, variantKey, alignmentSuffix)
+	if err != nil {
+		return err
+	}
+	return s.putObject(ctx, key, alignment, "application/json")
+}
+
+func (s *S3Storage) GetAlignment(ctx context.Context, pasteID, variantKey string) ([]byte, error) {
+	key, err := translationKey(pasteID, variantKey, alignmentSuffix)
+	if err != nil {
+		return nil, err
+	}
+	return s.getObject(ctx, key)
+}
+
+func (s *S3Storage) GetRomanization(ctx context.Context, pasteID, variantKey string) (string, error) {
+	key, err := translationKey(pasteID, variantKey, romanizedSuffix)
+	if err != nil {
+		return "", err
+	}
+	return s.getText(ctx, key)
+}
+
+// DeleteRomanization removes the romanization of a translation that has
+// been replaced. Deleting one that does not exist is not an error.
+func (s *S3Storage) DeleteRomanization(ctx context.Context, pasteID, variantKey string) error {
+	key, err := translationKey(pasteID, variantKey, romanizedSuffix)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (s *S3Storage) putText(ctx context.Context, key, text string) error {
+	return s.putObject(ctx, key, []byte(text), "text/plain; charset=utf-8")
+}
+
+func (s *S3Storage) getText(ctx context.Context, key string) (string, error) {
+	body, err := s.getObject(ctx, key)
+	return string(body), err
+}
+
+func (s *S3Storage) putObject(ctx context.Context, key string, body []byte, contentType string) error {
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(s.bucketName),
 		Key:         aws.String(key),
-		Body:        strings.NewReader(translation),
-		ContentType: aws.String("text/plain; charset=utf-8"),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
 	})
 	return err
 }
 
-func (s *S3Storage) GetTranslation(ctx context.Context, pasteID, language string) (string, error) {
-	key := fmt.Sprintf("pastes/%s/translations/%s.txt", pasteID, language)
+func (s *S3Storage) getObject(ctx context.Context, key string) ([]byte, error) {
 	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucketName),
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer result.Body.Close()
 
-	body, err := io.ReadAll(result.Body)
-	if err != nil {
-		return "", err
-	}
+	return io.ReadAll(result.Body)
+}
 
-	return string(body), nil
+func translationKey(pasteID, variantKey, suffix string) (string, error) {
+	if !variantKeyPattern.MatchString(variantKey) {
+		return "", fmt.Errorf("invalid translation variant %q", variantKey)
+	}
+	return fmt.Sprintf("pastes/%s/translations/%s%s", pasteID, variantKey, suffix), nil
 }
@@ -0,0 +1,216 @@
+package translate
+
+import (
+	"math"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/lingopaste/backend/internal/models"
+)
+
+// Alignment costs on top of the length mismatch of a bead: merging two
+// segments into one is common in translation, dropping one entirely is not.
+const (
+	alignMergePenalty = 1.0
+	alignSkipPenalty  = 4.0
+)
+
+// span is a byte range of a text, end exclusive.
+type span struct {
+	start, end int
+}
+
+// bead groups consecutive source and target segments that translate each
+// other; either side may be empty.
+type bead struct {
+	source, target []span
+}
+
+// alignMoves are the bead shapes considered: 1-1, merges and skips.
+var alignMoves = [][2]int{{1, 1}, {2, 1}, {1, 2}, {1, 0}, {0, 1}}
+
+// Align pairs the paragraphs of source with those of its translation and,
+// within paired paragraphs, their sentences. Pairs are matched by length
+// in the manner of Gale and Church, so no provider call is needed. Spans
+// are measured in UTF-16 code units, which is how browsers index strings.
+func Align(source, translation string) []models.AlignedPair {
+	ratio := 1.0
+	if n := utf8.RuneCountInString(source); n > 0 {
+		ratio = float64(utf8.RuneCountInString(translation)) / float64(n)
+	}
+
+	var beads []bead
+	for _, paragraphs := range alignSpans(source, translation, paragraphSpans(source), paragraphSpans(translation), ratio) {
+		if len(paragraphs.source) != 1 || len(paragraphs.target) != 1 {
+			beads = append(beads, paragraphs)
+			continue
+		}
+		sourceSentences := sentenceSpans(source, paragraphs.source[0])
+		targetSentences := sentenceSpans(translation, paragraphs.target[0])
+		if len(sourceSentences) == 1 && len(targetSentences) == 1 {
+			beads = append(beads, paragraphs)
+			continue
+		}
+		beads = append(beads, alignSpans(source, translation, sourceSentences, targetSentences, ratio)...)
+	}
+
+	sourceOffsets, targetOffsets := utf16Offsets(source), utf16Offsets(translation)
+	pairs := make([]models.AlignedPair, 0, len(beads))
+	sourceEnd, targetEnd := 0, 0
+	for _, b := range beads {
+		s := cover(b.source, sourceEnd)
+		t := cover(b.target, targetEnd)
+		sourceEnd, targetEnd = s.end, t.end
+		pairs = append(pairs, models.AlignedPair{
+			Source: models.TextSpan{Start: sourceOffsets[s.start], End: sourceOffsets[s.end]},
+			Target: models.TextSpan{Start: targetOffsets[t.start], End: targetOffsets[t.end]},
+		})
+	}
+	return pairs
+}
+
+// alignSpans finds the cheapest sequence of beads covering both lists.
+func alignSpans(source, target string, sourceSpans, targetSpans []span, ratio float64) []bead {
+	sourceLengths, targetLengths := spanLengths(source, sourceSpans), spanLengths(target, targetSpans)
+	n, m := len(sourceSpans), len(targetSpans)
+	cost := make([][]float64, n+1)
+	move := make([][]int, n+1)
+	for i := range cost {
+		cost[i] = make([]float64, m+1)
+		move[i] = make([]int, m+1)
+		for j := range cost[i] {
+			cost[i][j] = math.Inf(1)
+		}
+	}
+	cost[0][0] = 0
+
+	for i := 0; i <= n; i++ {
+		for j := 0; j <= m; j++ {
+			if math.IsInf(cost[i][j], 1) {
+				continue
+			}
+			for k, mv := range alignMoves {
+				ni, nj := i+mv[0], j+mv[1]
+				if ni > n || nj > m {
+					continue
+				}
+				c := cost[i][j] + beadCost(sum(sourceLengths[i:ni]), sum(targetLengths[j:nj]), ratio)
+				switch {
+				case mv[0] == 0 || mv[1] == 0:
+					c += alignSkipPenalty
+				case mv[0] > 1 || mv[1] > 1:
+					c += alignMergePenalty
+				}
+				if c < cost[ni][nj] {
+					cost[ni][nj] = c
+					move[ni][nj] = k
+				}
+			}
+		}
+	}
+
+	var beads []bead
+	for i, j := n, m; i > 0 || j > 0; {
+		mv := alignMoves[move[i][j]]
+		beads = append(beads, bead{source: sourceSpans[i-mv[0] : i], target: targetSpans[j-mv[1] : j]})
+		i, j = i-mv[0], j-mv[1]
+	}
+	for l, r := 0, len(beads)-1; l < r; l, r = l+1, r-1 {
+		beads[l], beads[r] = beads[r], beads[l]
+	}
+	return beads
+}
+
+// beadCost measures how far the lengths of a bead are from what ratio
+// predicts, relative to their size.
+func beadCost(sourceLength, targetLength int, ratio float64) float64 {
+	expected := float64(sourceLength) * ratio
+	return math.Abs(float64(targetLength)-expected) / math.Sqrt(expected+float64(targetLength)+1)
+}
+
+// spanLengths returns the length of each span of text in characters.
+func spanLengths(text string, spans []span) []int {
+	lengths := make([]int, len(spans))
+	for i, s := range spans {
+		lengths[i] = utf8.RuneCountInString(text[s.start:s.end])
+	}
+	return lengths
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// paragraphSpans returns the trimmed, non-empty paragraphs of text, keeping
+// fenced code blocks whole.
+func paragraphSpans(text string) []span {
+	var spans []span
+	offset := 0
+	for _, paragraph := range splitParagraphs(text) {
+		if s, ok := trimmedSpan(paragraph, offset); ok {
+			spans = append(spans, s)
+		}
+		offset += len(paragraph)
+	}
+	return spans
+}
+
+// sentenceSpans splits a paragraph span of text into sentences. Code
+// blocks are not split.
+func sentenceSpans(text string, paragraph span) []span {
+	body := text[paragraph.start:paragraph.end]
+	if fenceLine.MatchString(body) {
+		return []span{paragraph}
+	}
+
+	var spans []span
+	offset := paragraph.start
+	for _, sentence := range splitAfter(body, sentenceBreak) {
+		if s, ok := trimmedSpan(sentence, offset); ok {
+			spans = append(spans, s)
+		}
+		offset += len(sentence)
+	}
+	return spans
+}
+
+// trimmedSpan returns the span of piece, found at offset, without its
+// surrounding whitespace.
+func trimmedSpan(piece string, offset int) (span, bool) {
+	lead, core, _ := splitSpace(piece)
+	if core == "" {
+		return span{}, false
+	}
+	start := offset + len(lead)
+	return span{start, start + len(core)}, true
+}
+
+// cover returns the span from the first to the last of spans, or an empty
+// span at pos when there are none.
+func cover(spans []span, pos int) span {
+	if len(spans) == 0 {
+		return span{pos, pos}
+	}
+	return span{spans[0].start, spans[len(spans)-1].end}
+}
+
+// utf16Offsets maps every byte offset of text to its offset in UTF-16 code
+// units.
+func utf16Offsets(text string) []int {
+	offsets := make([]int, len(text)+1)
+	units := 0
+	for i := 0; i < len(text); {
+		r, width := utf8.DecodeRuneInString(text[i:])
+		for b := 0; b < width; b++ {
+			offsets[i+b] = units
+		}
+		units += utf16.RuneLen(r)
+		i += width
+	}
+	offsets[len(text)] = units
+	return offsets
+}
@@ -0,0 +1,148 @@
+package translate
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// ChunkedTranslator splits long texts into segments that fit within the
+// provider's output budget, translates them concurrently and stitches the
+// results back together with the original whitespace between them.
+type ChunkedTranslator struct {
+	inner       Translator
+	maxTokens   int
+	concurrency int
+}
+
+func NewChunkedTranslator(inner Translator, maxTokens, concurrency int) *ChunkedTranslator {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &ChunkedTranslator{
+		inner:       inner,
+		maxTokens:   maxTokens,
+		concurrency: concurrency,
+	}
+}
+
+// DetectLanguage only needs a representative sample, so it inspects the
+// first segment rather than the whole text.
+func (t *ChunkedTranslator) DetectLanguage(ctx context.Context, text string) (string, error) {
+	return t.inner.DetectLanguage(ctx, Segment(text, t.maxTokens)[0])
+}
+
+func (t *ChunkedTranslator) Translate(ctx context.Context, text string, opts Options) (string, error) {
+	segments := Segment(text, t.maxTokens)
+	if len(segments) == 1 {
+		return t.inner.Translate(ctx, text, opts)
+	}
+
+	results, err := t.translateSegments(ctx, segments, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(results, ""), nil
+}
+
+// TranslateStream emits each segment, in order, as soon as it and every
+// segment before it have been translated.
+func (t *ChunkedTranslator) TranslateStream(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error) {
+	segments := Segment(text, t.maxTokens)
+	if len(segments) == 1 {
+		if streamer, ok := t.inner.(StreamingTranslator); ok {
+			return streamer.TranslateStream(ctx, text, opts, onChunk)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results, done, errc := t.startSegments(ctx, segments, opts)
+
+	var full strings.Builder
+	for i := range segments {
+		select {
+		case <-done[i]:
+		case err := <-errc:
+			return "", err
+		}
+		// A failed segment closes its channel too; surface its error first.
+		select {
+		case err := <-errc:
+			return "", err
+		default:
+		}
+
+		full.WriteString(results[i])
+		if err := onChunk(results[i]); err != nil {
+			return "", err
+		}
+	}
+
+	return full.String(), nil
+}
+
+func (t *ChunkedTranslator) translateSegments(ctx context.Context, segments []string, opts Options) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results, done, errc := t.startSegments(ctx, segments, opts)
+	for i := range done {
+		<-done[i]
+	}
+
+	select {
+	case err := <-errc:
+		return nil, err
+	default:
+		return results, nil
+	}
+}
+
+// startSegments translates segments on a bounded pool of goroutines. Each
+// segment's channel in done is closed once its slot in results is final;
+// the first error is delivered on the returned error channel and cancels
+// the remaining work via ctx.
+func (t *ChunkedTranslator) startSegments(ctx context.Context, segments []string, opts Options) ([]string, []chan struct{}, <-chan error) {
+	results := make([]string, len(segments))
+	done := make([]chan struct{}, len(segments))
+	errc := make(chan error, 1)
+	sem := make(chan struct{}, t.concurrency)
+
+	var once sync.Once
+	fail := func(err error) {
+		once.Do(func() { errc <- err })
+	}
+
+	for i, segment := range segments {
+		done[i] = make(chan struct{})
+		go func(i int, segment string) {
+			defer close(done[i])
+
+			lead, core, trail := splitSpace(segment)
+			if core == "" {
+				results[i] = segment
+				return
+			}
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				fail(ctx.Err())
+				return
+			}
+
+			translated, err := t.inner.Translate(ctx, core, opts)
+			if err != nil {
+				fail(err)
+				return
+			}
+			results[i] = lead + strings.TrimSpace(translated) + trail
+		}(i, segment)
+	}
+
+	return results, done, errc
+}
@@ -0,0 +1,260 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Code, URLs and file paths are replaced by placeholder tokens such as ⟦C0⟧
+// before text is sent to a provider, and restored afterwards. The brackets
+// are unusual enough that models copy them verbatim rather than translate them.
+const (
+	placeholderOpen  = "⟦"
+	placeholderClose = "⟧"
+)
+
+// maxPlaceholderRetries is how many times a translation that lost or
+// duplicated placeholders is retried before giving up.
+const maxPlaceholderRetries = 1
+
+var (
+	placeholderToken = regexp.MustCompile(`⟦C(\d+)⟧`)
+	// anyToken matches the tokens of every masking layer.
+	anyToken = regexp.MustCompile(`⟦[A-Z]\d+⟧`)
+
+	// Patterns are applied in order; later patterns only see text that
+	// earlier ones left unmasked.
+	fencedCode  = regexp.MustCompile("(?ms)^[ \t]*(?:```|~~~)[^\n]*\n(?:.*?^[ \t]*(?:```|~~~)[ \t]*$|.*\\z)")
+	inlineCode  = regexp.MustCompile("``[^\n]+?``|`[^`\n]+`")
+	stackFrames = regexp.MustCompile(`(?m)^[ \t]+at [^\n]+$|^[ \t]*File "[^"\n]+", line \d+[^\n]*$|^\t\S+\.go:\d+[^\n]*$|^goroutine \d+ \[[^\n]*$`)
+	urls        = regexp.MustCompile("https?://[^\\s<>\"'`]*[^\\s<>\"'`.,;:!?)\\]]")
+	filePaths   = regexp.MustCompile(`(?:~|\.{1,2})?/[\w.@-]+(?:/[\w.@-]+)+/?|\b[\w.-]+(?:/[\w.-]+)+\.[A-Za-z0-9]{1,6}\b|\b[A-Za-z]:\\[^\s"'<>|*?]+`)
+
+	// codeComments finds the text of line and block comments inside a
+	// fenced block when comment translation is requested.
+	codeComments = regexp.MustCompile(`(?m)(?:^|[ \t])(?://[ \t]?|#[ \t]+|--[ \t]+)([^\n]*)|/\*+[ \t]*([\s\S]*?)[ \t]*\*+/`)
+)
+
+// ErrPlaceholderMismatch reports a translation in which masked code did not
+// survive intact.
+type ErrPlaceholderMismatch struct {
+	Missing    []string
+	Duplicated []string
+	Unknown    []string
+}
+
+func (e *ErrPlaceholderMismatch) Error() string {
+	return fmt.Sprintf("placeholder mismatch: missing %v, duplicated %v, unknown %v", e.Missing, e.Duplicated, e.Unknown)
+}
+
+// maskedText is text with code, or placeholders, replaced by tokens of
+// the given kind: ⟦C0⟧ for code and ⟦P0⟧ for placeholders.
+type maskedText struct {
+	kind      string
+	token     *regexp.Regexp
+	text      string
+	originals []string
+}
+
+// maskCode replaces fenced and inline code, stack traces, URLs and file paths
+// in text with placeholder tokens. With translateComments, comments inside
+// fenced blocks are left as translatable prose.
+func maskCode(text string, translateComments bool) *maskedText {
+	m := &maskedText{kind: "C", token: placeholderToken}
+
+	if translateComments {
+		text = fencedCode.ReplaceAllStringFunc(text, m.maskExceptComments)
+	} else {
+		text = fencedCode.ReplaceAllStringFunc(text, m.mask)
+	}
+	for _, pattern := range []*regexp.Regexp{inlineCode, stackFrames, urls, filePaths} {
+		text = pattern.ReplaceAllStringFunc(text, m.mask)
+	}
+
+	m.text = text
+	return m
+}
+
+func (m *maskedText) mask(original string) string {
+	token := placeholderOpen + m.kind + strconv.Itoa(len(m.originals)) + placeholderClose
+	m.originals = append(m.originals, original)
+	return token
+}
+
+// maskExceptComments masks a fenced block piecewise so that only the text of
+// its comments remains visible.
+func (m *maskedText) maskExceptComments(block string) string {
+	var spans [][2]int
+	for _, loc := range codeComments.FindAllStringSubmatchIndex(block, -1) {
+		for group := 1; group <= 2; group++ {
+			start, end := loc[2*group], loc[2*group+1]
+			if start >= 0 && strings.TrimSpace(block[start:end]) != "" {
+				spans = append(spans, [2]int{start, end})
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return m.mask(block)
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	var b strings.Builder
+	pos := 0
+	for _, span := range spans {
+		if span[0] < pos {
+			continue
+		}
+		if span[0] > pos {
+			b.WriteString(m.mask(block[pos:span[0]]))
+		}
+		b.WriteString(block[span[0]:span[1]])
+		pos = span[1]
+	}
+	if pos < len(block) {
+		b.WriteString(m.mask(block[pos:]))
+	}
+	return b.String()
+}
+
+// hasProse reports whether anything other than placeholders and whitespace
+// is left to translate.
+func (m *maskedText) hasProse() bool {
+	return strings.TrimSpace(m.token.ReplaceAllString(m.text, "")) != ""
+}
+
+// restore puts the originals back into translated, verifying that every
+// token appears exactly once.
+func (m *maskedText) restore(translated string) (string, error) {
+	seen := make([]int, len(m.originals))
+	var unknown []string
+	for _, match := range m.token.FindAllStringSubmatch(translated, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n >= len(m.originals) {
+			unknown = append(unknown, match[0])
+			continue
+		}
+		seen[n]++
+	}
+
+	var missing, duplicated []string
+	for i, count := range seen {
+		token := placeholderOpen + m.kind + strconv.Itoa(i) + placeholderClose
+		switch {
+		case count == 0:
+			missing = append(missing, token)
+		case count > 1:
+			duplicated = append(duplicated, token)
+		}
+	}
+	if len(missing) > 0 || len(duplicated) > 0 || len(unknown) > 0 {
+		return "", &ErrPlaceholderMismatch{Missing: missing, Duplicated: duplicated, Unknown: unknown}
+	}
+
+	return m.expand(translated), nil
+}
+
+func (m *maskedText) expand(text string) string {
+	return m.token.ReplaceAllStringFunc(text, func(token string) string {
+		n, err := strconv.Atoi(m.token.FindStringSubmatch(token)[1])
+		if err != nil || n >= len(m.originals) {
+			return token
+		}
+		return m.originals[n]
+	})
+}
+
+// CodeAwareTranslator keeps code, URLs and file paths out of the provider's
+// reach by masking them before translation and restoring them afterwards.
+type CodeAwareTranslator struct {
+	inner Translator
+}
+
+func NewCodeAwareTranslator(inner Translator) *CodeAwareTranslator {
+	return &CodeAwareTranslator{inner: inner}
+}
+
+// DetectLanguage looks only at the prose, since code is nearly always
+// English-looking regardless of the surrounding language.
+func (t *CodeAwareTranslator) DetectLanguage(ctx context.Context, text string) (string, error) {
+	m := maskCode(text, false)
+	if !m.hasProse() {
+		return t.inner.DetectLanguage(ctx, text)
+	}
+	return t.inner.DetectLanguage(ctx, placeholderToken.ReplaceAllString(m.text, " "))
+}
+
+func (t *CodeAwareTranslator) Translate(ctx context.Context, text string, opts Options) (string, error) {
+	m := maskCode(text, opts.TranslateCodeComments)
+	if len(m.originals) == 0 {
+		return t.inner.Translate(ctx, text, opts)
+	}
+	if !m.hasProse() {
+		return text, nil
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxPlaceholderRetries; attempt++ {
+		translated, err := t.inner.Translate(ctx, m.text, opts)
+		if err != nil {
+			return "", err
+		}
+		restored, err := m.restore(translated)
+		if err == nil {
+			return restored, nil
+		}
+		lastErr = err
+	}
+
+	return "", fmt.Errorf("failed to preserve code: %w", lastErr)
+}
+
+// TranslateStream restores placeholders as chunks arrive, holding back any
+// token that is split across chunks. Streamed output cannot be retried, so a
+// placeholder mismatch is reported as an error after the fact.
+func (t *CodeAwareTranslator) TranslateStream(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error) {
+	streamer, ok := t.inner.(StreamingTranslator)
+	m := maskCode(text, opts.TranslateCodeComments)
+	if !ok || !m.hasProse() {
+		translated, err := t.Translate(ctx, text, opts)
+		if err != nil {
+			return "", err
+		}
+		return translated, onChunk(translated)
+	}
+
+	var pending strings.Builder
+	translated, err := streamer.TranslateStream(ctx, m.text, opts, func(chunk string) error {
+		pending.WriteString(chunk)
+		buffered := pending.String()
+
+		ready := buffered
+		if open := strings.LastIndex(buffered, placeholderOpen); open >= 0 && !strings.Contains(buffered[open:], placeholderClose) {
+			ready = buffered[:open]
+		}
+		pending.Reset()
+		pending.WriteString(buffered[len(ready):])
+
+		if ready == "" {
+			return nil
+		}
+		return onChunk(m.expand(ready))
+	})
+	if err != nil {
+		return "", err
+	}
+	if pending.Len() > 0 {
+		if err := onChunk(m.expand(pending.String())); err != nil {
+			return "", err
+		}
+	}
+
+	restored, err := m.restore(translated)
+	if err != nil {
+		return "", fmt.Errorf("failed to preserve code: %w", err)
+	}
+	return restored, nil
+}
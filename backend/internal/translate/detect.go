@@ -0,0 +1,227 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrUnknownLanguage is returned when no supported language could be
+// identified for a text.
+var ErrUnknownLanguage = errors.New("unknown language")
+
+// minDetectionEvidence is how many stopword hits a Latin-script guess needs
+// before it is considered fully supported.
+const minDetectionEvidence = 4
+
+// scriptLanguages maps scripts used by a single supported language to that
+// language. Han is handled separately because Japanese mixes it with kana.
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	code  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// stopwords holds very frequent words of each Latin-script language. They
+// are deliberately short lists; the margin between the best and second best
+// language, not the raw count, drives confidence.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "of", "to", "in", "that", "it", "with", "for", "this", "you", "not", "have", "be", "on", "what", "which", "there", "would", "will"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "de", "en", "un", "una", "por", "con", "para", "del", "se", "no", "lo", "como", "pero", "muy", "está", "son"},
+	"fr": {"le", "la", "les", "et", "est", "que", "de", "des", "un", "une", "pour", "dans", "pas", "sur", "avec", "ce", "qui", "vous", "nous", "mais", "sont", "du", "au"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "zu", "mit", "den", "von", "auf", "für", "sich", "auch", "dem", "wir", "sie", "ich", "sind", "wird", "oder"},
+	"it": {"il", "di", "che", "è", "e", "la", "per", "un", "una", "non", "sono", "con", "del", "della", "gli", "questo", "anche", "come", "ma", "nel", "alla", "più"},
+	"pt": {"o", "os", "as", "e", "é", "que", "de", "do", "da", "em", "um", "uma", "para", "com", "não", "se", "por", "mais", "dos", "das", "você", "está", "são"},
+	"nl": {"de", "het", "een", "en", "is", "van", "dat", "niet", "op", "te", "zijn", "voor", "met", "ook", "maar", "wij", "ik", "je", "er", "deze", "wordt", "naar"},
+	"pl": {"i", "w", "nie", "na", "się", "jest", "to", "z", "że", "do", "co", "jak", "ale", "tak", "po", "od", "dla", "już", "są", "przez", "tylko"},
+	"tr": {"ve", "bir", "bu", "için", "ile", "da", "de", "çok", "ne", "ama", "gibi", "daha", "olarak", "değil", "var", "ben", "sen", "mi", "kadar", "olan"},
+	"vi": {"và", "là", "của", "có", "không", "được", "những", "một", "các", "cho", "với", "này", "người", "trong", "đã", "để", "khi", "tôi", "bạn"},
+	"sv": {"och", "att", "det", "är", "som", "en", "på", "för", "med", "inte", "av", "till", "har", "den", "jag", "om", "var", "men", "ett", "kan", "också"},
+	"da": {"og", "at", "det", "er", "som", "en", "på", "for", "med", "ikke", "af", "til", "har", "den", "jeg", "vi", "var", "men", "et", "kan", "også"},
+	"no": {"og", "at", "det", "er", "som", "en", "på", "for", "med", "ikke", "av", "til", "har", "den", "jeg", "vi", "var", "men", "et", "kan", "også", "ikkje"},
+	"fi": {"ja", "on", "ei", "että", "se", "oli", "hän", "mutta", "kun", "niin", "myös", "tämä", "ovat", "jos", "minä", "sinä", "kuin", "vain", "joka", "sen"},
+}
+
+// distinctiveLetters are characters that strongly suggest one language.
+var distinctiveLetters = map[rune]string{
+	'ñ': "es", '¿': "es", '¡': "es",
+	'ã': "pt", 'õ': "pt",
+	'ß': "de",
+	'ł': "pl", 'ą': "pl", 'ę': "pl", 'ś': "pl", 'ź': "pl", 'ż': "pl", 'ń': "pl",
+	'ğ': "tr", 'ş': "tr", 'ı': "tr",
+	'ư': "vi", 'ơ': "vi", 'đ': "vi", 'ạ': "vi", 'ả': "vi", 'ế': "vi", 'ệ': "vi", 'ộ': "vi",
+	'ø': "no", 'æ': "da",
+}
+
+var stopwordIndex = buildStopwordIndex()
+
+func buildStopwordIndex() map[string][]string {
+	index := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, word := range words {
+			index[word] = append(index[word], lang)
+		}
+	}
+	return index
+}
+
+// DetectLocal identifies the language of text without calling a provider.
+// It returns the language code and a confidence between 0 and 1; a code of
+// "" means no guess could be made.
+func DetectLocal(text string) (string, float64) {
+	letters, han, kana := 0, 0, 0
+	scriptCounts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		default:
+			for _, script := range scriptLanguages {
+				if unicode.Is(script.table, r) {
+					scriptCounts[script.code]++
+					break
+				}
+			}
+		}
+	}
+	if letters == 0 {
+		return "", 0
+	}
+
+	// Non-Latin scripts identify the language almost by themselves.
+	if kana > 0 && kana+han > letters/2 {
+		return "ja", float64(kana+han) / float64(letters)
+	}
+	if han > letters/2 {
+		return "zh", float64(han) / float64(letters)
+	}
+	for code, count := range scriptCounts {
+		if count > letters/2 {
+			return code, float64(count) / float64(letters)
+		}
+	}
+
+	return detectLatin(text)
+}
+
+func detectLatin(text string) (string, float64) {
+	scores := make(map[string]int)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		for _, lang := range stopwordIndex[word] {
+			scores[lang]++
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		if lang, ok := distinctiveLetters[r]; ok {
+			scores[lang] += 2
+		}
+	}
+
+	best, bestScore, secondScore := "", 0, 0
+	for lang, score := range scores {
+		switch {
+		case score > bestScore || (score == bestScore && lang < best):
+			secondScore = bestScore
+			best, bestScore = lang, score
+		case score > secondScore:
+			secondScore = score
+		}
+	}
+	if bestScore == 0 {
+		return "", 0
+	}
+
+	margin := float64(bestScore-secondScore) / float64(bestScore)
+	support := float64(bestScore) / minDetectionEvidence
+	if support > 1 {
+		support = 1
+	}
+	return best, margin * support
+}
+
+// NormalizeLanguageCode turns a provider's answer ("en", "EN.", "english",
+// "'pt-BR'", "zh_Hant") into a supported language tag.
+func NormalizeLanguageCode(raw string) (string, bool) {
+	code := strings.TrimFunc(strings.TrimSpace(raw), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	if tag, err := NormalizeTag(code); err == nil {
+		return tag, true
+	}
+	for _, lang := range registry {
+		if strings.EqualFold(code, lang.Name) && !strings.Contains(lang.Code, "-") {
+			return lang.Code, true
+		}
+	}
+	return "", false
+}
+
+// DetectingTranslator answers DetectLanguage locally when it is confident
+// and only asks the wrapped provider for ambiguous texts. Whatever it
+// returns is a supported language code.
+type DetectingTranslator struct {
+	inner         Translator
+	minConfidence float64
+}
+
+func NewDetectingTranslator(inner Translator, minConfidence float64) *DetectingTranslator {
+	return &DetectingTranslator{
+		inner:         inner,
+		minConfidence: minConfidence,
+	}
+}
+
+func (t *DetectingTranslator) DetectLanguage(ctx context.Context, text string) (string, error) {
+	local, confidence := DetectLocal(text)
+	if local != "" && confidence >= t.minConfidence {
+		return local, nil
+	}
+
+	raw, err := t.inner.DetectLanguage(ctx, text)
+	if err != nil {
+		if local != "" {
+			return local, nil
+		}
+		return "", err
+	}
+	if code, ok := NormalizeLanguageCode(raw); ok {
+		return code, nil
+	}
+	if local != "" {
+		return local, nil
+	}
+	return "", fmt.Errorf("%w: provider answered %q", ErrUnknownLanguage, raw)
+}
+
+func (t *DetectingTranslator) Translate(ctx context.Context, text string, opts Options) (string, error) {
+	return t.inner.Translate(ctx, text, opts)
+}
+
+func (t *DetectingTranslator) TranslateStream(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error) {
+	if streamer, ok := t.inner.(StreamingTranslator); ok {
+		return streamer.TranslateStream(ctx, text, opts, onChunk)
+	}
+	translated, err := t.inner.Translate(ctx, text, opts)
+	if err != nil {
+		return "", err
+	}
+	return translated, onChunk(translated)
+}
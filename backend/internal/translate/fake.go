@@ -0,0 +1,56 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// FakeTranslator is a deterministic backend that never leaves the process.
+// It is intended for local development and CI where no provider is reachable.
+type FakeTranslator struct {
+	language string
+}
+
+func NewFakeTranslator() *FakeTranslator {
+	return &FakeTranslator{language: "en"}
+}
+
+func (t *FakeTranslator) DetectLanguage(ctx context.Context, text string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	recordUsage(ctx, "fake", EstimateTokens(text), 1, true)
+	return t.language, nil
+}
+
+func (t *FakeTranslator) Translate(ctx context.Context, text string, opts Options) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	translation := fmt.Sprintf("[%s:%s] %s", opts.TargetLanguage, opts.Tone, text)
+	if opts.Romanize {
+		translation = fmt.Sprintf("[%s:romanized] %s", opts.TargetLanguage, text)
+	}
+	recordUsage(ctx, "fake", EstimateTokens(text), EstimateTokens(translation), true)
+	return translation, nil
+}
+
+// TranslateStream emits the fake translation one line at a time.
+func (t *FakeTranslator) TranslateStream(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error) {
+	translation, err := t.Translate(ctx, text, opts)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.SplitAfter(translation, "\n") {
+		if line == "" {
+			continue
+		}
+		if err := onChunk(line); err != nil {
+			return "", err
+		}
+	}
+
+	return translation, nil
+}
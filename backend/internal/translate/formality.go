@@ -0,0 +1,157 @@
+package translate
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Formality levels. Honorific is only offered for languages with a
+// separate honorific register, such as Japanese keigo.
+const (
+	FormalityInformal  = "informal"
+	FormalityFormal    = "formal"
+	FormalityHonorific = "honorific"
+)
+
+var (
+	ErrInvalidFormality     = errors.New("invalid formality")
+	ErrUnsupportedFormality = errors.New("formality not supported for language")
+)
+
+// Formality levels offered by languages in the registry.
+var (
+	tvFormality     = []string{FormalityInformal, FormalityFormal}
+	keigoFormality  = []string{FormalityInformal, FormalityFormal, FormalityHonorific}
+	formalityLevels = []string{FormalityInformal, FormalityFormal, FormalityHonorific}
+)
+
+// formalityHints spell out what each level means in a language, keyed by
+// registry code and falling back to the base language.
+var formalityHints = map[string]map[string]string{
+	"es": {
+		FormalityInformal: `Address the reader with "tú" (plural "vosotros" in Spain, "ustedes" elsewhere).`,
+		FormalityFormal:   `Address the reader with "usted" (plural "ustedes").`,
+	},
+	"es-419": {
+		FormalityInformal: `Address the reader with "tú" (plural "ustedes").`,
+		FormalityFormal:   `Address the reader with "usted" (plural "ustedes").`,
+	},
+	"fr": {
+		FormalityInformal: `Address the reader with "tu".`,
+		FormalityFormal:   `Address the reader with "vous".`,
+	},
+	"de": {
+		FormalityInformal: `Address the reader with "du" (plural "ihr").`,
+		FormalityFormal:   `Address the reader with "Sie".`,
+	},
+	"it": {
+		FormalityInformal: `Address the reader with "tu" (plural "voi").`,
+		FormalityFormal:   `Address the reader with "Lei".`,
+	},
+	"pt": {
+		FormalityInformal: `Address the reader with "tu".`,
+		FormalityFormal:   `Address the reader with "o senhor"/"a senhora" or "você" as appropriate in European Portuguese.`,
+	},
+	"pt-BR": {
+		FormalityInformal: `Address the reader with "você".`,
+		FormalityFormal:   `Address the reader with "o senhor"/"a senhora".`,
+	},
+	"nl": {
+		FormalityInformal: `Address the reader with "je"/"jij".`,
+		FormalityFormal:   `Address the reader with "u".`,
+	},
+	"pl": {
+		FormalityInformal: `Address the reader with "ty".`,
+		FormalityFormal:   `Address the reader with "Pan"/"Pani" (plural "Państwo").`,
+	},
+	"ru": {
+		FormalityInformal: `Address the reader with "ты".`,
+		FormalityFormal:   `Address the reader with "Вы".`,
+	},
+	"tr": {
+		FormalityInformal: `Address the reader with "sen".`,
+		FormalityFormal:   `Address the reader with "siz".`,
+	},
+	"fi": {
+		FormalityInformal: `Address the reader with "sinä".`,
+		FormalityFormal:   `Address the reader with "Te".`,
+	},
+	"hi": {
+		FormalityInformal: `Address the reader with "तुम".`,
+		FormalityFormal:   `Address the reader with "आप".`,
+	},
+	"zh": {
+		FormalityInformal: `Address the reader with "你".`,
+		FormalityFormal:   `Address the reader with "您".`,
+	},
+	"ja": {
+		FormalityInformal: "Use plain form (常体, だ/である) without keigo.",
+		FormalityFormal:   "Use polite form (丁寧語, です/ます).",
+		FormalityHonorific: "Use full keigo: respectful language (尊敬語) for the reader and their actions " +
+			"and humble language (謙譲語) for the writer's own.",
+	},
+	"ko": {
+		FormalityInformal:  "Use the intimate or plain speech level (반말).",
+		FormalityFormal:    "Use the polite speech level (해요체).",
+		FormalityHonorific: "Use the deferential speech level (합쇼체) with honorific forms for the reader.",
+	},
+	"vi": {
+		FormalityInformal: "Use casual pronouns and omit politeness particles.",
+		FormalityFormal:   `Use respectful pronouns such as "quý vị" or "bạn" and polite particles like "ạ".`,
+	},
+	"th": {
+		FormalityInformal: "Use casual speech without polite particles.",
+		FormalityFormal:   `Use polite speech with the particles "ครับ"/"ค่ะ".`,
+	},
+}
+
+// IsValidFormality reports whether formality is a known level. The empty
+// string means no preference.
+func IsValidFormality(formality string) bool {
+	if formality == "" {
+		return true
+	}
+	for _, level := range formalityLevels {
+		if level == formality {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckFormality returns an error unless formality can be requested for
+// the language with the given normalized tag.
+func CheckFormality(language, formality string) error {
+	if formality == "" {
+		return nil
+	}
+	if !IsValidFormality(formality) {
+		return fmt.Errorf("%w: %q", ErrInvalidFormality, formality)
+	}
+	lang, ok := LookupLanguage(language)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedLanguage, language)
+	}
+	for _, level := range lang.FormalityLevels {
+		if level == formality {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s in %s", ErrUnsupportedFormality, formality, language)
+}
+
+// formalityInstruction returns the prompt text for the formality in opts,
+// or "" when none applies to the target language.
+func formalityInstruction(opts Options) string {
+	if opts.Formality == "" || CheckFormality(opts.TargetLanguage, opts.Formality) != nil {
+		return ""
+	}
+	hints, ok := formalityHints[opts.TargetLanguage]
+	if !ok {
+		hints = formalityHints[BaseLanguage(opts.TargetLanguage)]
+	}
+	if hint, ok := hints[opts.Formality]; ok {
+		return hint
+	}
+	return fmt.Sprintf("Use a %s register when addressing the reader.", opts.Formality)
+}
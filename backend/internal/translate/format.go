@@ -0,0 +1,155 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Formats a paste can be in. Everything that is not recognized as a
+// structured format is plain text.
+const (
+	FormatText = "text"
+	FormatSRT  = "srt"
+	FormatVTT  = "vtt"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatPO   = "po"
+	FormatHTML = "html"
+)
+
+// ErrStructureMismatch is returned when a translation could not be fitted
+// back into the structure of the original.
+var ErrStructureMismatch = errors.New("translation does not keep the structure of the original")
+
+// fileTypes maps formats to the file extension and MIME type of their
+// downloads.
+var fileTypes = map[string][2]string{
+	FormatText: {".txt", "text/plain"},
+	FormatSRT:  {".srt", "application/x-subrip"},
+	FormatVTT:  {".vtt", "text/vtt"},
+	FormatJSON: {".json", "application/json"},
+	FormatYAML: {".yaml", "application/yaml"},
+	FormatPO:   {".po", "text/x-gettext-translation"},
+	FormatHTML: {".html", "text/html"},
+}
+
+// DetectFormat returns the format text is in.
+func DetectFormat(text string) string {
+	text = strings.TrimPrefix(text, "\uFEFF")
+	switch {
+	case isWebVTT(text):
+		return FormatVTT
+	case isSRT(text):
+		return FormatSRT
+	case isHTML(text):
+		return FormatHTML
+	case isPO(text):
+		return FormatPO
+	case isJSONLocale(text):
+		return FormatJSON
+	case isYAMLLocale(text):
+		return FormatYAML
+	default:
+		return FormatText
+	}
+}
+
+// FileType returns the file extension and MIME type for downloads of a
+// paste in format.
+func FileType(format string) (extension, mimeType string) {
+	fileType, ok := fileTypes[format]
+	if !ok {
+		fileType = fileTypes[FormatText]
+	}
+	return fileType[0], fileType[1]
+}
+
+// TranslatableText returns the part of text in format that is prose, for
+// language detection.
+func TranslatableText(format, text string) string {
+	switch format {
+	case FormatSRT, FormatVTT:
+		return subtitleText(text)
+	case FormatJSON, FormatYAML, FormatPO:
+		return localeText(format, text)
+	case FormatHTML:
+		return htmlText(text)
+	default:
+		return text
+	}
+}
+
+// formatInstruction returns extra prompt rules for structured formats.
+func formatInstruction(opts Options) string {
+	switch opts.Format {
+	case FormatSRT, FormatVTT:
+		return "\n- The text consists of subtitle cues, each starting with a marker like ⟦S1⟧: keep every marker exactly once and in order, each followed by the translation of that cue alone" +
+			"\n- Tokens like ⟦T0⟧ are styling tags: copy every one of them exactly, once, around the same words" +
+			"\n- Keep each cue about as long as the original so it can be read on screen"
+	case FormatJSON, FormatYAML, FormatPO:
+		instruction := "\n- The text consists of strings from a localization file, each starting with a marker like ⟦S1⟧: keep every marker exactly once and in order, each followed by the translation of that string alone" +
+			"\n- Tokens like ⟦T0⟧ are placeholders or markup filled in by the application: copy every one of them exactly, once, where the grammar of the translation needs them"
+		if opts.PluralForm != "" {
+			instruction += "\n- Every string is the plural form of a message used when its count n is " + opts.PluralForm + ": translate it in the grammatical form such counts take"
+		}
+		return instruction
+	case FormatHTML:
+		return "\n- The text consists of passages of an HTML document, each starting with a marker like ⟦S1⟧: keep every marker exactly once and in order, each followed by the translation of that passage alone" +
+			"\n- Tokens like ⟦T0⟧ are HTML tags: copy every one of them exactly, once, in the same order, around the corresponding words"
+	default:
+		return ""
+	}
+}
+
+// FormatTranslator translates structured formats piecewise, leaving their
+// structure untouched, and passes plain text through. The format comes
+// from Options.Format.
+type FormatTranslator struct {
+	inner Translator
+}
+
+func NewFormatTranslator(inner Translator) *FormatTranslator {
+	return &FormatTranslator{inner: inner}
+}
+
+// DetectLanguage ignores markup such as subtitle timings.
+func (t *FormatTranslator) DetectLanguage(ctx context.Context, text string) (string, error) {
+	return t.inner.DetectLanguage(ctx, TranslatableText(DetectFormat(text), text))
+}
+
+func (t *FormatTranslator) Translate(ctx context.Context, text string, opts Options) (string, error) {
+	switch opts.Format {
+	case FormatSRT, FormatVTT:
+		return t.translateSubtitles(ctx, text, opts, nil)
+	case FormatJSON, FormatYAML, FormatPO:
+		return t.translateLocale(ctx, text, opts)
+	case FormatHTML:
+		return t.translateHTML(ctx, text, opts)
+	default:
+		return t.inner.Translate(ctx, text, opts)
+	}
+}
+
+func (t *FormatTranslator) TranslateStream(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error) {
+	switch opts.Format {
+	case FormatSRT, FormatVTT:
+		return t.translateSubtitles(ctx, text, opts, onChunk)
+	case FormatJSON, FormatYAML, FormatPO, FormatHTML:
+		// A file is only valid once complete, so it is sent in one piece.
+		translated, err := t.Translate(ctx, text, opts)
+		if err != nil {
+			return "", err
+		}
+		return translated, onChunk(translated)
+	}
+
+	if streamer, ok := t.inner.(StreamingTranslator); ok {
+		return streamer.TranslateStream(ctx, text, opts, onChunk)
+	}
+	translated, err := t.inner.Translate(ctx, text, opts)
+	if err != nil {
+		return "", err
+	}
+	return translated, onChunk(translated)
+}
@@ -0,0 +1,110 @@
+package translate
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lingopaste/backend/internal/models"
+)
+
+// GlossaryTerm is a glossary entry resolved for one target language.
+type GlossaryTerm struct {
+	Source         string
+	Target         string
+	DoNotTranslate bool
+}
+
+// expected is the text the translation must contain for this term.
+func (g GlossaryTerm) expected() string {
+	if g.DoNotTranslate {
+		return g.Source
+	}
+	return g.Target
+}
+
+// GlossaryTermsFor resolves glossary for targetLanguage, keeping only the
+// terms that occur in source so the prompt stays small.
+func GlossaryTermsFor(glossary *models.Glossary, targetLanguage, source string) []GlossaryTerm {
+	if glossary == nil {
+		return nil
+	}
+
+	var terms []GlossaryTerm
+	for _, entry := range glossary.Entries {
+		if !containsTerm(source, entry.Term) {
+			continue
+		}
+		if entry.DoNotTranslate {
+			terms = append(terms, GlossaryTerm{Source: entry.Term, DoNotTranslate: true})
+			continue
+		}
+		// An entry for "pt" also covers "pt-BR" unless it has its own rendering.
+		target, ok := entry.Translations[targetLanguage]
+		if !ok {
+			target, ok = entry.Translations[BaseLanguage(targetLanguage)]
+		}
+		if ok && target != "" {
+			terms = append(terms, GlossaryTerm{Source: entry.Term, Target: target})
+		}
+	}
+	return terms
+}
+
+// CheckGlossary reports every term whose required rendering is missing from
+// translated.
+func CheckGlossary(translated string, terms []GlossaryTerm) []models.GlossaryViolation {
+	var violations []models.GlossaryViolation
+	for _, term := range terms {
+		if !containsTerm(translated, term.expected()) {
+			violations = append(violations, models.GlossaryViolation{
+				Term:     term.Source,
+				Expected: term.expected(),
+			})
+		}
+	}
+	return violations
+}
+
+// containsTerm matches term case-insensitively, on word boundaries when the
+// term starts and ends with a word character. Scripts written without spaces
+// fall back to a plain substring match.
+func containsTerm(text, term string) bool {
+	if term == "" {
+		return false
+	}
+
+	first, _ := utf8.DecodeRuneInString(term)
+	last, _ := utf8.DecodeLastRuneInString(term)
+	if isASCIIWordRune(first) && isASCIIWordRune(last) {
+		pattern := `(?i)\b` + regexp.QuoteMeta(term) + `\b`
+		return regexp.MustCompile(pattern).MatchString(text)
+	}
+	return strings.Contains(strings.ToLower(text), strings.ToLower(term))
+}
+
+func isASCIIWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+func glossaryInstruction(terms []GlossaryTerm) string {
+	if len(terms) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\nGlossary (these renderings are mandatory):\n")
+	for _, term := range terms {
+		if term.DoNotTranslate {
+			b.WriteString("- " + quoteTerm(term.Source) + " → keep exactly as is, do not translate\n")
+		} else {
+			b.WriteString("- " + quoteTerm(term.Source) + " → " + quoteTerm(term.Target) + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func quoteTerm(term string) string {
+	return `"` + strings.ReplaceAll(term, `"`, `'`) + `"`
+}
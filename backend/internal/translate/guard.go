@@ -0,0 +1,136 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+)
+
+// ErrSuspiciousOutput is returned when every attempt at a translation
+// produced output that does not look like a translation.
+var ErrSuspiciousOutput = errors.New("suspicious translation output")
+
+const (
+	maxSuspiciousRetries = 2
+
+	// Below minRatioTokens the length of a translation says little.
+	minRatioTokens = 8
+	minLengthRatio = 0.25
+	maxLengthRatio = 4.0
+
+	// Language checks only trust confident local detection.
+	minGuardConfidence = 0.8
+)
+
+// metaCommentary matches a model talking about the task instead of doing
+// it: preambles, refusals and notes.
+var metaCommentary = regexp.MustCompile(`(?im)^\s*(?:here(?:'s| is) (?:the|your) translat|sure[,!.]|certainly[,!.]|of course[,!.]|i(?:'m| am) sorry|i can(?:not|'t) |as an ai\b|translation:|translated text:|\(?note:)`)
+
+// GuardedTranslator checks each translation before letting it through, so
+// that outputs derailed by instructions hidden in the paste are retried
+// instead of stored. A translation is rejected when its length is far off
+// the source's, when it is confidently in the source language or a script
+// the target does not use, or when it contains meta-commentary the source
+// does not.
+type GuardedTranslator struct {
+	inner Translator
+}
+
+func NewGuardedTranslator(inner Translator) *GuardedTranslator {
+	return &GuardedTranslator{inner: inner}
+}
+
+func (t *GuardedTranslator) DetectLanguage(ctx context.Context, text string) (string, error) {
+	return t.inner.DetectLanguage(ctx, text)
+}
+
+func (t *GuardedTranslator) Translate(ctx context.Context, text string, opts Options) (string, error) {
+	var reason string
+	for attempt := 0; attempt <= maxSuspiciousRetries; attempt++ {
+		translated, err := t.inner.Translate(ctx, text, opts)
+		if err != nil {
+			return "", err
+		}
+		if reason = checkTranslation(text, translated, opts); reason == "" {
+			return translated, nil
+		}
+		metrics.Add("guard_rejections", 1)
+		log.Printf("Rejected suspicious translation to %s (attempt %d): %s", opts.TargetLanguage, attempt+1, reason)
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrSuspiciousOutput, reason)
+}
+
+// TranslateStream checks the translation once it is complete. Chunks have
+// already been shown by then, so a rejected stream is an error rather than
+// a retry; either way it is never stored.
+func (t *GuardedTranslator) TranslateStream(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error) {
+	streamer, ok := t.inner.(StreamingTranslator)
+	if !ok {
+		translated, err := t.Translate(ctx, text, opts)
+		if err != nil {
+			return "", err
+		}
+		return translated, onChunk(translated)
+	}
+	translated, err := streamer.TranslateStream(ctx, text, opts, onChunk)
+	if err != nil {
+		return "", err
+	}
+	if reason := checkTranslation(text, translated, opts); reason != "" {
+		metrics.Add("guard_rejections", 1)
+		return "", fmt.Errorf("%w: %s", ErrSuspiciousOutput, reason)
+	}
+	return translated, nil
+}
+
+// checkTranslation returns why translated does not look like a translation
+// of source, or "" if it does.
+func checkTranslation(source, translated string, opts Options) string {
+	sourceTokens, translatedTokens := EstimateTokens(source), EstimateTokens(translated)
+	if sourceTokens >= minRatioTokens {
+		ratio := float64(translatedTokens) / float64(sourceTokens)
+		if ratio < minLengthRatio || ratio > maxLengthRatio {
+			return fmt.Sprintf("length ratio %.2f", ratio)
+		}
+	}
+
+	if metaCommentary.MatchString(translated) && !metaCommentary.MatchString(source) {
+		return "meta-commentary"
+	}
+
+	target := BaseLanguage(opts.TargetLanguage)
+	outputLang, outputConfidence := DetectLocal(anyToken.ReplaceAllString(translated, " "))
+	if opts.Romanize {
+		if outputConfidence >= minGuardConfidence && !usesLatinScript(outputLang) {
+			return fmt.Sprintf("output is still in %s script", outputLang)
+		}
+		return ""
+	}
+	if outputConfidence < minGuardConfidence || outputLang == target {
+		return ""
+	}
+	if usesLatinScript(outputLang) != usesLatinScript(target) {
+		return fmt.Sprintf("output is in %s, not %s", outputLang, target)
+	}
+	sourceLang, sourceConfidence := DetectLocal(anyToken.ReplaceAllString(source, " "))
+	if sourceConfidence >= minGuardConfidence && sourceLang == outputLang && sourceLang != target {
+		return fmt.Sprintf("output is still in %s", outputLang)
+	}
+	return ""
+}
+
+// usesLatinScript reports whether a base language is written in Latin script.
+func usesLatinScript(code string) bool {
+	if code == "ja" || code == "zh" {
+		return false
+	}
+	for _, script := range scriptLanguages {
+		if script.code == code {
+			return false
+		}
+	}
+	return true
+}
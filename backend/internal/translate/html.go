@@ -0,0 +1,437 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	// htmlStart recognizes documents and fragments that open with markup.
+	htmlStart = regexp.MustCompile(`(?i)^(?:<!doctype html|<html[\s>]|<[a-z][a-z0-9]*[\s/>])`)
+	htmlClose = regexp.MustCompile(`</[A-Za-z][A-Za-z0-9]*\s*>`)
+
+	// htmlAttribute finds the translatable attributes in the raw text of a
+	// tag, capturing the value with its quotes.
+	htmlAttribute = regexp.MustCompile(`(?i)\s(?:alt|title|placeholder)\s*=\s*("[^"]*"|'[^']*'|[^\s"'=<>` + "`" + `]+)`)
+)
+
+// htmlInline are the elements that can appear inside a sentence. A run of
+// text and inline elements is translated as one segment, with the tags
+// masked.
+var htmlInline = map[string]bool{
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true, "big": true, "br": true,
+	"cite": true, "data": true, "del": true, "dfn": true, "em": true, "font": true,
+	"i": true, "img": true, "ins": true, "label": true, "mark": true, "q": true,
+	"s": true, "small": true, "span": true, "strike": true, "strong": true, "sub": true,
+	"sup": true, "time": true, "tt": true, "u": true, "wbr": true,
+	"code": true, "kbd": true, "samp": true, "var": true,
+}
+
+// htmlSkipped are the elements whose content is never translated. Inline
+// ones are masked as a whole inside their sentence.
+var htmlSkipped = map[string]bool{
+	"script": true, "style": true, "pre": true, "template": true, "svg": true, "math": true, "textarea": true,
+	"code": true, "kbd": true, "samp": true, "var": true,
+}
+
+var htmlVoid = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+var (
+	htmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\u00a0", "&nbsp;")
+	htmlQuotEscaper = strings.NewReplacer("&", "&amp;", `"`, "&quot;", "\u00a0", "&nbsp;")
+	htmlAposEscaper = strings.NewReplacer("&", "&amp;", "'", "&#39;", "\u00a0", "&nbsp;")
+)
+
+func isHTML(text string) bool {
+	text = strings.TrimSpace(text)
+	return htmlStart.MatchString(text) && htmlClose.MatchString(text)
+}
+
+// htmlTag is the raw text of a tag, or of a skipped element, with the
+// translatable attribute values in it.
+type htmlTag struct {
+	raw   string
+	name  string
+	end   bool
+	attrs []htmlAttr
+}
+
+// htmlAttr is an attribute value spanning raw[start:end], quotes included.
+type htmlAttr struct {
+	start, end int
+	segment    int
+}
+
+// htmlRun is a stretch of text and inline tags translated as a sentence.
+type htmlRun struct {
+	parts       []any // raw text as a string, or *htmlTag
+	lead, trail string
+	segment     int
+}
+
+// htmlDocument is an HTML document or fragment as a sequence of raw text,
+// tags and runs. Everything but runs and attribute values is reproduced
+// byte for byte.
+type htmlDocument struct {
+	pieces   []any // string, *htmlTag or *htmlRun
+	segments []maskedSegment
+	tags     [][]*htmlTag
+}
+
+func parseHTML(text string) (*htmlDocument, error) {
+	d := &htmlDocument{}
+	z := html.NewTokenizer(strings.NewReader(text))
+	run := &htmlRun{segment: -1}
+	flush := func() {
+		if len(run.parts) > 0 {
+			d.addRun(run)
+			d.pieces = append(d.pieces, run)
+			run = &htmlRun{segment: -1}
+		}
+	}
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+		raw := string(z.Raw())
+
+		switch tt {
+		case html.TextToken:
+			run.parts = append(run.parts, raw)
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			name, _ := z.TagName()
+			tag := &htmlTag{raw: raw, name: string(name), end: tt == html.EndTagToken}
+			if tt == html.StartTagToken && (htmlSkipped[tag.name] || noTranslate(raw)) && !htmlVoid[tag.name] {
+				tag.raw += skipElement(z, tag.name)
+			} else if !tag.end {
+				d.addAttributes(tag)
+			}
+			if htmlInline[tag.name] {
+				run.parts = append(run.parts, tag)
+			} else {
+				flush()
+				d.pieces = append(d.pieces, tag)
+			}
+		default:
+			flush()
+			d.pieces = append(d.pieces, raw)
+		}
+	}
+	flush()
+	return d, nil
+}
+
+// noTranslate reports whether a start tag carries translate="no".
+func noTranslate(raw string) bool {
+	z := html.NewTokenizer(strings.NewReader(raw))
+	z.Next()
+	for {
+		key, value, more := z.TagAttr()
+		if string(key) == "translate" && strings.EqualFold(string(value), "no") {
+			return true
+		}
+		if !more {
+			return false
+		}
+	}
+}
+
+// skipElement consumes the content and end tag of the element name that
+// was just opened, returning their raw text.
+func skipElement(z *html.Tokenizer, name string) string {
+	var b strings.Builder
+	depth := 1
+	for depth > 0 {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		b.Write(z.Raw())
+		if tag, _ := z.TagName(); string(tag) == name {
+			switch tt {
+			case html.StartTagToken:
+				depth++
+			case html.EndTagToken:
+				depth--
+			}
+		}
+	}
+	return b.String()
+}
+
+// addAttributes makes a segment of every translatable attribute of tag.
+func (d *htmlDocument) addAttributes(tag *htmlTag) {
+	for _, loc := range htmlAttribute.FindAllStringSubmatchIndex(tag.raw, -1) {
+		start, end := loc[2], loc[3]
+		value := tag.raw[start:end]
+		if value[0] == '"' || value[0] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		value = html.UnescapeString(value)
+		attr := htmlAttr{start: start, end: end, segment: -1}
+		if strings.IndexFunc(value, unicode.IsLetter) >= 0 && strings.TrimSpace(value) == value {
+			attr.segment = len(d.segments)
+			d.segments = append(d.segments, maskedSegment{text: value})
+			d.tags = append(d.tags, nil)
+		}
+		tag.attrs = append(tag.attrs, attr)
+	}
+}
+
+// addRun makes a segment of a run that has text to translate, masking its
+// tags.
+func (d *htmlDocument) addRun(run *htmlRun) {
+	var (
+		b    strings.Builder
+		tags []*htmlTag
+	)
+	hasText := false
+	for _, part := range run.parts {
+		switch part := part.(type) {
+		case string:
+			part = html.UnescapeString(part)
+			b.WriteString(part)
+			if strings.IndexFunc(part, unicode.IsLetter) >= 0 {
+				hasText = true
+			}
+		case *htmlTag:
+			fmt.Fprintf(&b, "%sT%d%s", placeholderOpen, len(tags), placeholderClose)
+			tags = append(tags, part)
+		}
+	}
+	if !hasText {
+		return
+	}
+
+	lead, core, trail := splitSpace(b.String())
+	run.lead, run.trail = lead, trail
+	run.segment = len(d.segments)
+	segment := maskedSegment{text: core, tags: make([]string, len(tags))}
+	for i, tag := range tags {
+		segment.tags[i] = tag.raw
+	}
+	d.segments = append(d.segments, segment)
+	d.tags = append(d.tags, tags)
+}
+
+// inOrder reports whether the tags of a translated run kept their order,
+// so that the elements they open and close still nest the same way.
+func inOrder(masked string) bool {
+	last := -1
+	for _, match := range tagToken.FindAllStringSubmatch(masked, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n != last+1 {
+			return false
+		}
+		last = n
+	}
+	return true
+}
+
+// render writes the document with the translations of j, which holds its
+// segments.
+func (d *htmlDocument) render(j *segmentJob) string {
+	var b strings.Builder
+	for _, piece := range d.pieces {
+		switch piece := piece.(type) {
+		case string:
+			b.WriteString(piece)
+		case *htmlTag:
+			b.WriteString(piece.render(j))
+		case *htmlRun:
+			if piece.segment < 0 {
+				for _, part := range piece.parts {
+					if tag, ok := part.(*htmlTag); ok {
+						b.WriteString(tag.render(j))
+					} else {
+						b.WriteString(part.(string))
+					}
+				}
+				continue
+			}
+			tags := d.tags[piece.segment]
+			b.WriteString(htmlTextEscaper.Replace(piece.lead))
+			masked := j.masked[piece.segment]
+			last := 0
+			for _, loc := range tagToken.FindAllStringSubmatchIndex(masked, -1) {
+				n, _ := strconv.Atoi(masked[loc[2]:loc[3]])
+				b.WriteString(htmlTextEscaper.Replace(masked[last:loc[0]]))
+				b.WriteString(tags[n].render(j))
+				last = loc[1]
+			}
+			b.WriteString(htmlTextEscaper.Replace(masked[last:]))
+			b.WriteString(htmlTextEscaper.Replace(piece.trail))
+		}
+	}
+	return b.String()
+}
+
+// render returns the raw tag with its attribute values translated.
+func (t *htmlTag) render(j *segmentJob) string {
+	var b strings.Builder
+	last := 0
+	for _, attr := range t.attrs {
+		if attr.segment < 0 {
+			continue
+		}
+		b.WriteString(t.raw[last:attr.start])
+		if quote := t.raw[attr.start]; quote == '\'' {
+			b.WriteString("'" + htmlAposEscaper.Replace(j.results[attr.segment]) + "'")
+		} else {
+			b.WriteString(`"` + htmlQuotEscaper.Replace(j.results[attr.segment]) + `"`)
+		}
+		last = attr.end
+	}
+	b.WriteString(t.raw[last:])
+	return b.String()
+}
+
+// htmlText returns the translatable text of an HTML document.
+func htmlText(text string) string {
+	d, err := parseHTML(text)
+	if err != nil {
+		return text
+	}
+	texts := make([]string, len(d.segments))
+	for i, segment := range d.segments {
+		texts[i] = tagToken.ReplaceAllString(segment.text, "")
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// translateHTML translates the text and the alt, title and placeholder
+// attributes of an HTML document in place and checks that the result
+// parses to the same tree.
+func (t *FormatTranslator) translateHTML(ctx context.Context, text string, opts Options) (string, error) {
+	d, err := parseHTML(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	j := newSegmentJob(d.segments)
+	j.valid = func(i int, masked, restored string) bool {
+		return inOrder(masked)
+	}
+	if err := t.translateSegments(ctx, j, opts); err != nil {
+		return "", err
+	}
+
+	translated := d.render(j)
+	if err := compareHTML(text, translated); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrStructureMismatch, err)
+	}
+	return translated, nil
+}
+
+// compareHTML parses both documents and compares their trees, ignoring
+// text outside skipped elements and the values of translatable attributes.
+func compareHTML(original, translated string) error {
+	a, err := html.Parse(strings.NewReader(original))
+	if err != nil {
+		return err
+	}
+	b, err := html.Parse(strings.NewReader(translated))
+	if err != nil {
+		return err
+	}
+	return compareNodes(a, b, false)
+}
+
+func compareNodes(a, b *html.Node, skipped bool) error {
+	if a.Type != b.Type || a.Data != b.Data {
+		return fmt.Errorf("%s became %s", describeNode(a), describeNode(b))
+	}
+	switch a.Type {
+	case html.TextNode, html.CommentNode, html.DoctypeNode:
+		if (skipped || a.Type != html.TextNode) && a.Data != b.Data {
+			return fmt.Errorf("%s changed", describeNode(a))
+		}
+	case html.ElementNode:
+		if !sameAttributes(a, b) {
+			return fmt.Errorf("attributes of <%s> changed", a.Data)
+		}
+		skipped = skipped || htmlSkipped[a.Data] || hasNoTranslate(a)
+	}
+
+	childrenA, childrenB := structuralChildren(a, skipped), structuralChildren(b, skipped)
+	if len(childrenA) != len(childrenB) {
+		return fmt.Errorf("children of %s changed", describeNode(a))
+	}
+	for i := range childrenA {
+		if err := compareNodes(childrenA[i], childrenB[i], skipped); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structuralChildren returns the children of n that must match, which are
+// all of them inside skipped elements and all but text elsewhere.
+func structuralChildren(n *html.Node, skipped bool) []*html.Node {
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if skipped || c.Type != html.TextNode {
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+func sameAttributes(a, b *html.Node) bool {
+	if len(a.Attr) != len(b.Attr) {
+		return false
+	}
+	for i, attr := range a.Attr {
+		other := b.Attr[i]
+		if attr.Namespace != other.Namespace || attr.Key != other.Key {
+			return false
+		}
+		switch attr.Key {
+		case "alt", "title", "placeholder":
+		default:
+			if attr.Val != other.Val {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func hasNoTranslate(n *html.Node) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "translate" && strings.EqualFold(attr.Val, "no") {
+			return true
+		}
+	}
+	return false
+}
+
+func describeNode(n *html.Node) string {
+	switch n.Type {
+	case html.ElementNode:
+		return "<" + n.Data + ">"
+	case html.TextNode:
+		return "text"
+	case html.CommentNode:
+		return "comment"
+	case html.DoctypeNode:
+		return "doctype"
+	default:
+		return "document"
+	}
+}
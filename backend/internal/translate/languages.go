@@ -0,0 +1,164 @@
+package translate
+
+import "github.com/lingopaste/backend/internal/models"
+
+// Text directions reported by the language registry.
+const (
+	DirectionLTR = "ltr"
+	DirectionRTL = "rtl"
+)
+
+// registry is the single list of languages the service translates into.
+// Codes are normalized BCP-47 tags; regional and script variants are listed
+// explicitly next to their base language. Formality is offered where the
+// language grammatically distinguishes how the reader is addressed, and
+// romanization for languages not written in Latin script.
+var registry = []models.Language{
+	{Code: "en", Name: "English", NativeName: "English", Direction: DirectionLTR, SupportsTone: true},
+	{Code: "en-US", Name: "American English", NativeName: "English (US)", Direction: DirectionLTR, SupportsTone: true},
+	{Code: "en-GB", Name: "British English", NativeName: "English (UK)", Direction: DirectionLTR, SupportsTone: true},
+	{Code: "es", Name: "Spanish", NativeName: "Español", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "es-ES", Name: "European Spanish", NativeName: "Español de España", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "es-MX", Name: "Mexican Spanish", NativeName: "Español de México", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "es-419", Name: "Latin American Spanish", NativeName: "Español latinoamericano", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "fr", Name: "French", NativeName: "Français", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "fr-CA", Name: "Canadian French", NativeName: "Français canadien", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "de", Name: "German", NativeName: "Deutsch", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "it", Name: "Italian", NativeName: "Italiano", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "pt", Name: "Portuguese", NativeName: "Português", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "pt-BR", Name: "Brazilian Portuguese", NativeName: "Português do Brasil", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "pt-PT", Name: "European Portuguese", NativeName: "Português europeu", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "ru", Name: "Russian", NativeName: "Русский", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality, Romanization: "BGN/PCGN romanization"},
+	{Code: "ja", Name: "Japanese", NativeName: "日本語", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: keigoFormality, Romanization: "Hepburn romaji"},
+	{Code: "ko", Name: "Korean", NativeName: "한국어", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: keigoFormality, Romanization: "Revised Romanization of Korean"},
+	{Code: "zh", Name: "Chinese", NativeName: "中文", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality, Romanization: "Hanyu Pinyin with tone marks"},
+	{Code: "zh-Hans", Name: "Simplified Chinese", NativeName: "简体中文", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality, Romanization: "Hanyu Pinyin with tone marks"},
+	{Code: "zh-Hant", Name: "Traditional Chinese", NativeName: "繁體中文", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality, Romanization: "Hanyu Pinyin with tone marks"},
+	{Code: "ar", Name: "Arabic", NativeName: "العربية", Direction: DirectionRTL, SupportsTone: true, Romanization: "ALA-LC romanization"},
+	{Code: "hi", Name: "Hindi", NativeName: "हिन्दी", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality, Romanization: "IAST"},
+	{Code: "nl", Name: "Dutch", NativeName: "Nederlands", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "pl", Name: "Polish", NativeName: "Polski", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "tr", Name: "Turkish", NativeName: "Türkçe", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "vi", Name: "Vietnamese", NativeName: "Tiếng Việt", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "th", Name: "Thai", NativeName: "ไทย", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "sv", Name: "Swedish", NativeName: "Svenska", Direction: DirectionLTR, SupportsTone: true},
+	{Code: "da", Name: "Danish", NativeName: "Dansk", Direction: DirectionLTR, SupportsTone: true},
+	{Code: "fi", Name: "Finnish", NativeName: "Suomi", Direction: DirectionLTR, SupportsTone: true, FormalityLevels: tvFormality},
+	{Code: "no", Name: "Norwegian", NativeName: "Norsk", Direction: DirectionLTR, SupportsTone: true},
+}
+
+// tagAliases maps common region-based tags to the registry entry they mean.
+var tagAliases = map[string]string{
+	"zh-CN": "zh-Hans",
+	"zh-SG": "zh-Hans",
+	"zh-TW": "zh-Hant",
+	"zh-HK": "zh-Hant",
+	"zh-MO": "zh-Hant",
+}
+
+// pluralRule is how the gettext catalogs of a language choose between the
+// forms of a plural message.
+type pluralRule struct {
+	// header is the value of the catalog's Plural-Forms header.
+	header string
+	// forms describes the counts each form is used for, for the model.
+	forms []string
+	// singular is the form translated from msgid rather than msgid_plural,
+	// or -1 if there is none.
+	singular int
+}
+
+var (
+	oneOtherPlurals = pluralRule{header: "nplurals=2; plural=(n != 1);", forms: []string{"n = 1", "any other n, including 0"}, singular: 0}
+	zeroOnePlurals  = pluralRule{header: "nplurals=2; plural=(n > 1);", forms: []string{"n = 0 or 1", "n ≥ 2"}, singular: 0}
+	noPlurals       = pluralRule{header: "nplurals=1; plural=0;", forms: []string{"any n"}, singular: -1}
+)
+
+// pluralRules are keyed by registry code, falling back to the base language.
+var pluralRules = map[string]pluralRule{
+	"en":    oneOtherPlurals,
+	"es":    oneOtherPlurals,
+	"fr":    zeroOnePlurals,
+	"de":    oneOtherPlurals,
+	"it":    oneOtherPlurals,
+	"pt":    oneOtherPlurals,
+	"pt-BR": zeroOnePlurals,
+	"ru": {
+		header:   "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+		forms:    []string{"n = 1, 21, 31, … but not 11", "n = 2–4, 22–24, 32–34, … but not 12–14", "n = 0, 5–20, 25–30, …"},
+		singular: 0,
+	},
+	"ja": noPlurals,
+	"ko": noPlurals,
+	"zh": noPlurals,
+	"ar": {
+		header:   "nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);",
+		forms:    []string{"n = 0", "n = 1", "n = 2", "n = 3–10, 103–110, …", "n = 11–99, 111–199, …", "n = 100–102, 200–202, …"},
+		singular: 1,
+	},
+	"hi": oneOtherPlurals,
+	"nl": oneOtherPlurals,
+	"pl": {
+		header:   "nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+		forms:    []string{"n = 1", "n = 2–4, 22–24, 32–34, … but not 12–14", "n = 0, 5–21, 25–31, …"},
+		singular: 0,
+	},
+	"tr": oneOtherPlurals,
+	"vi": noPlurals,
+	"th": noPlurals,
+	"sv": oneOtherPlurals,
+	"da": oneOtherPlurals,
+	"fi": oneOtherPlurals,
+	"no": oneOtherPlurals,
+}
+
+func pluralRuleFor(code string) (pluralRule, bool) {
+	if rule, ok := pluralRules[code]; ok {
+		return rule, true
+	}
+	rule, ok := pluralRules[BaseLanguage(code)]
+	return rule, ok
+}
+
+var registryIndex = buildRegistryIndex()
+
+func buildRegistryIndex() map[string]models.Language {
+	index := make(map[string]models.Language, len(registry))
+	for _, lang := range registry {
+		index[lang.Code] = lang
+	}
+	return index
+}
+
+// Languages returns every supported target language.
+func Languages() []models.Language {
+	languages := make([]models.Language, len(registry))
+	copy(languages, registry)
+	return languages
+}
+
+// LookupLanguage returns the registry entry for a normalized tag.
+func LookupLanguage(code string) (models.Language, bool) {
+	lang, ok := registryIndex[code]
+	return lang, ok
+}
+
+// IsKnownLanguage reports whether code is a supported language tag.
+func IsKnownLanguage(code string) bool {
+	_, ok := registryIndex[code]
+	return ok
+}
+
+// Romanization returns the system a language is romanized with, or false
+// if it cannot be romanized.
+func Romanization(code string) (string, bool) {
+	lang, ok := registryIndex[code]
+	return lang.Romanization, ok && lang.Romanization != ""
+}
+
+func getLanguageName(code string) string {
+	if lang, ok := registryIndex[code]; ok {
+		return lang.Name
+	}
+	return code
+}
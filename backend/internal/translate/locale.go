@@ -0,0 +1,693 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// localeFile is a parsed i18n resource file. Its values are translated
+// while keys, comments and everything else are kept.
+type localeFile interface {
+	// values returns the translatable strings in document order.
+	values() []string
+	// render rebuilds the file with values replaced, in the same order.
+	render(values []string, opts Options) (string, error)
+	// shape describes everything but the values, for checking that a
+	// rendered file has the structure of the original.
+	shape() string
+}
+
+func parseLocale(format, text string) (localeFile, error) {
+	switch format {
+	case FormatJSON:
+		return parseJSONLocale(text)
+	case FormatYAML:
+		return parseYAMLLocale(text)
+	case FormatPO:
+		return parsePO(text)
+	default:
+		return nil, fmt.Errorf("not a locale format: %s", format)
+	}
+}
+
+// localeText returns the values of a locale file, for language detection.
+func localeText(format, text string) string {
+	file, err := parseLocale(format, text)
+	if err != nil {
+		return text
+	}
+	return strings.Join(file.values(), "\n\n")
+}
+
+// localeSource is a string to translate and, for the form of a plural
+// message, the counts that form is used for.
+type localeSource struct {
+	text string
+	form string
+}
+
+// localeSources returns the strings to translate into opts.TargetLanguage,
+// in the order render takes their translations. Only PO files have plural
+// messages, whose forms depend on the target language.
+func localeSources(file localeFile, opts Options) []localeSource {
+	if po, ok := file.(*poFile); ok {
+		return po.sources(opts)
+	}
+	var sources []localeSource
+	for _, value := range file.values() {
+		sources = append(sources, localeSource{text: value})
+	}
+	return sources
+}
+
+// translateLocale translates the values of a JSON, YAML or PO file, with
+// placeholders and markup masked, and checks that the rebuilt file parses
+// to the same structure. Each plural form is translated separately, with
+// the model told which counts it is for.
+func (t *FormatTranslator) translateLocale(ctx context.Context, text string, opts Options) (string, error) {
+	file, err := parseLocale(opts.Format, text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s file: %w", opts.Format, err)
+	}
+
+	sources := localeSources(file, opts)
+	translated := make([]string, len(sources))
+	var forms []string
+	byForm := make(map[string][]int)
+	for i, source := range sources {
+		translated[i] = source.text
+		if _, ok := byForm[source.form]; !ok {
+			forms = append(forms, source.form)
+		}
+		byForm[source.form] = append(byForm[source.form], i)
+	}
+	for _, form := range forms {
+		formOpts := opts
+		formOpts.PluralForm = form
+		if err := t.translateLocaleValues(ctx, sources, byForm[form], translated, formOpts); err != nil {
+			return "", err
+		}
+	}
+
+	rendered, err := file.render(translated, opts)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrStructureMismatch, err)
+	}
+	check, err := parseLocale(opts.Format, rendered)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrStructureMismatch, err)
+	}
+	if check.shape() != file.shape() {
+		return "", fmt.Errorf("%w: %s file changed shape", ErrStructureMismatch, opts.Format)
+	}
+	return rendered, nil
+}
+
+// translateLocaleValues translates the sources at indices into translated,
+// leaving those that have nothing to translate as they are.
+func (t *FormatTranslator) translateLocaleValues(ctx context.Context, sources []localeSource, indices []int, translated []string, opts Options) error {
+	var (
+		segments []maskedSegment
+		targets  []int
+	)
+	for _, i := range indices {
+		_, core, _ := splitSpace(sources[i].text)
+		segment := mask(core, mergeSpans(append(placeholderSpans(core), markupSpans(core)...)))
+		if strings.IndexFunc(tagToken.ReplaceAllString(segment.text, ""), unicode.IsLetter) < 0 {
+			continue
+		}
+		segments = append(segments, segment)
+		targets = append(targets, i)
+	}
+
+	j := newSegmentJob(segments)
+	j.valid = func(k int, masked, restored string) bool {
+		_, core, _ := splitSpace(sources[targets[k]].text)
+		return samePlaceholders(core, restored)
+	}
+	if err := t.translateSegments(ctx, j, opts); err != nil {
+		return err
+	}
+	for k, target := range targets {
+		lead, _, trail := splitSpace(sources[target].text)
+		translated[target] = lead + j.results[k] + trail
+	}
+	return nil
+}
+
+// jsonLocale is a JSON document whose string values, but not keys, are
+// translated. Values are replaced in place, so formatting is untouched.
+type jsonLocale struct {
+	text    string
+	strings []span
+	decoded []string
+	tokens  []string
+}
+
+// jsonFrame tracks a container while walking a JSON document.
+type jsonFrame struct {
+	object    bool
+	expectKey bool
+}
+
+func isJSONLocale(text string) bool {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return false
+	}
+	file, err := parseJSONLocale(text)
+	return err == nil && len(file.values()) > 0
+}
+
+func parseJSONLocale(text string) (*jsonLocale, error) {
+	if !json.Valid([]byte(text)) {
+		return nil, errors.New("invalid JSON")
+	}
+	f := &jsonLocale{text: text}
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+
+	var stack []jsonFrame
+	// consumed marks the end of a value in the enclosing container.
+	consumed := func() {
+		if n := len(stack); n > 0 && stack[n-1].object {
+			stack[n-1].expectKey = true
+		}
+	}
+	for {
+		offset := dec.InputOffset()
+		token, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := token.(type) {
+		case json.Delim:
+			switch v {
+			case '{', '[':
+				stack = append(stack, jsonFrame{object: v == '{', expectKey: v == '{'})
+			default:
+				stack = stack[:len(stack)-1]
+				consumed()
+			}
+			f.tokens = append(f.tokens, v.String())
+		case string:
+			if n := len(stack); n > 0 && stack[n-1].expectKey {
+				stack[n-1].expectKey = false
+				f.tokens = append(f.tokens, strconv.Quote(v)+":")
+				continue
+			}
+			start := int(offset)
+			for start < len(text) && text[start] != '"' {
+				start++
+			}
+			f.strings = append(f.strings, span{start, int(dec.InputOffset())})
+			f.decoded = append(f.decoded, v)
+			f.tokens = append(f.tokens, `""`)
+			consumed()
+		default:
+			f.tokens = append(f.tokens, fmt.Sprint(v))
+			consumed()
+		}
+	}
+	return f, nil
+}
+
+func (f *jsonLocale) values() []string {
+	return f.decoded
+}
+
+func (f *jsonLocale) render(values []string, opts Options) (string, error) {
+	var b strings.Builder
+	last := 0
+	for i, s := range f.strings {
+		b.WriteString(f.text[last:s.start])
+		if values[i] == f.decoded[i] {
+			b.WriteString(f.text[s.start:s.end])
+		} else {
+			var encoded bytes.Buffer
+			enc := json.NewEncoder(&encoded)
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(values[i]); err != nil {
+				return "", err
+			}
+			b.WriteString(strings.TrimSuffix(encoded.String(), "\n"))
+		}
+		last = s.end
+	}
+	b.WriteString(f.text[last:])
+	return b.String(), nil
+}
+
+func (f *jsonLocale) shape() string {
+	return strings.Join(f.tokens, " ")
+}
+
+var (
+	// yamlKeyLine matches a mapping key.
+	yamlKeyLine = regexp.MustCompile(`^(?:"[^"\n]*"|'[^'\n]*'|[^\s#:"'\-?{}\[\]&*!|>%@` + "`" + `][^:#\n]*?):(?:[ \t]|$)`)
+	// yaml11Bool matches plain scalars that YAML 1.1 parsers, such as the
+	// one Rails uses, read as booleans; they are not translated.
+	yaml11Bool = regexp.MustCompile(`^(?:y|Y|yes|Yes|YES|n|N|no|No|NO|on|On|ON|off|Off|OFF)$`)
+)
+
+// yamlLocale is a YAML stream whose string scalars, but not keys, are
+// translated. It is re-encoded, which keeps comments and key order but
+// normalizes layout.
+type yamlLocale struct {
+	docs    []*yaml.Node
+	scalars []*yaml.Node
+	indent  int
+}
+
+// isYAMLLocale reports whether text is a YAML mapping. Since almost any
+// text is valid YAML, every unindented line must be a key, comment or
+// document marker, and there must be at least two keys at any level.
+func isYAMLLocale(text string) bool {
+	keys := 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimLeft(line, " ")
+		switch {
+		case strings.TrimSpace(line) == "", strings.HasPrefix(line, "#"), line == "---", line == "...":
+		case yamlKeyLine.MatchString(trimmed):
+			keys++
+		case line[0] != ' ':
+			return false
+		}
+	}
+	if keys < 2 {
+		return false
+	}
+	file, err := parseYAMLLocale(text)
+	return err == nil && len(file.values()) > 0
+}
+
+func parseYAMLLocale(text string) (*yamlLocale, error) {
+	f := &yamlLocale{indent: yamlIndent(text)}
+	dec := yaml.NewDecoder(strings.NewReader(text))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			return nil, errors.New("YAML document is not a mapping")
+		}
+		f.docs = append(f.docs, &doc)
+		f.collect(doc.Content[0])
+	}
+	if len(f.docs) == 0 {
+		return nil, errors.New("empty YAML document")
+	}
+	return f, nil
+}
+
+// collect gathers the string scalars under node that are values.
+func (f *yamlLocale) collect(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			f.collect(node.Content[i])
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			f.collect(child)
+		}
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!str" && node.Value != "" && !(node.Style == 0 && yaml11Bool.MatchString(node.Value)) {
+			f.scalars = append(f.scalars, node)
+		}
+	}
+}
+
+// yamlIndent returns the indentation of the first indented line, which
+// the encoder reuses.
+func yamlIndent(text string) int {
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if n := len(line) - len(trimmed); n > 0 && strings.TrimSpace(trimmed) != "" && !strings.HasPrefix(trimmed, "- ") {
+			return n
+		}
+	}
+	return 2
+}
+
+func (f *yamlLocale) values() []string {
+	values := make([]string, len(f.scalars))
+	for i, node := range f.scalars {
+		values[i] = node.Value
+	}
+	return values
+}
+
+func (f *yamlLocale) render(values []string, opts Options) (string, error) {
+	originals := f.values()
+	for i, node := range f.scalars {
+		node.Value = values[i]
+	}
+	defer func() {
+		for i, node := range f.scalars {
+			node.Value = originals[i]
+		}
+	}()
+
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(f.indent)
+	for _, doc := range f.docs {
+		if err := enc.Encode(doc); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (f *yamlLocale) shape() string {
+	var b strings.Builder
+	var walk func(node *yaml.Node, value bool)
+	walk = func(node *yaml.Node, value bool) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			b.WriteString("{")
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i], false)
+				b.WriteString(":")
+				walk(node.Content[i+1], true)
+				b.WriteString(",")
+			}
+			b.WriteString("}")
+		case yaml.SequenceNode:
+			b.WriteString("[")
+			for _, child := range node.Content {
+				walk(child, true)
+				b.WriteString(",")
+			}
+			b.WriteString("]")
+		case yaml.AliasNode:
+			b.WriteString("*" + node.Value)
+		case yaml.ScalarNode:
+			if value && node.ShortTag() == "!!str" && node.Value != "" {
+				b.WriteString("!!str")
+			} else {
+				b.WriteString(node.ShortTag() + " " + strconv.Quote(node.Value))
+			}
+		}
+	}
+	for _, doc := range f.docs {
+		walk(doc.Content[0], false)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+var (
+	poDetect  = regexp.MustCompile(`(?m)^msgid[ \t]+"`)
+	poKeyword = regexp.MustCompile(`^(msgctxt|msgid|msgid_plural|msgstr(?:\[(\d+)\])?)[ \t]+"(.*)"[ \t]*$`)
+	poString  = regexp.MustCompile(`^[ \t]*"(.*)"[ \t]*$`)
+	// poLanguage and poPluralForms match fields of a PO header.
+	poLanguage    = regexp.MustCompile(`(?m)^Language:[ \t]*[^\n]*$`)
+	poPluralForms = regexp.MustCompile(`(?m)^Plural-Forms:[ \t]*[^\n]*$`)
+)
+
+// poField is a keyword of a PO entry with its value, spanning lines
+// [start, end) of the file.
+type poField struct {
+	keyword    string
+	value      string
+	start, end int
+}
+
+// poEntry is a message of a PO file. The msgstr fields are filled in with
+// the translation of msgid and msgid_plural.
+type poEntry struct {
+	context, id, plural string
+	plurals             bool
+	msgstrs             []poField
+}
+
+// poFile is a gettext catalog. Only msgstr lines are rewritten.
+type poFile struct {
+	lines   []string
+	newline string
+	entries []*poEntry
+}
+
+func isPO(text string) bool {
+	if !poDetect.MatchString(text) {
+		return false
+	}
+	file, err := parsePO(text)
+	return err == nil && len(file.values()) > 0
+}
+
+func parsePO(text string) (*poFile, error) {
+	f := &poFile{newline: "\n"}
+	if strings.Contains(text, "\r\n") {
+		f.newline = "\r\n"
+	}
+	f.lines = strings.SplitAfter(text, "\n")
+
+	var (
+		entry *poEntry
+		field *poField
+	)
+	finish := func(end int) {
+		if field != nil {
+			field.end = end
+			switch {
+			case field.keyword == "msgctxt":
+				entry.context = field.value
+			case field.keyword == "msgid":
+				entry.id = field.value
+			case field.keyword == "msgid_plural":
+				entry.plural, entry.plurals = field.value, true
+			default:
+				entry.msgstrs = append(entry.msgstrs, *field)
+			}
+		}
+		field = nil
+	}
+	for i, line := range f.lines {
+		line = strings.TrimRight(line, "\r\n")
+		if match := poKeyword.FindStringSubmatch(line); match != nil {
+			finish(i)
+			keyword := match[1]
+			if entry == nil || len(entry.msgstrs) > 0 && (keyword == "msgctxt" || keyword == "msgid") {
+				entry = &poEntry{}
+				f.entries = append(f.entries, entry)
+			}
+			field = &poField{keyword: keyword, value: unescapePO(match[3]), start: i}
+			continue
+		}
+		if match := poString.FindStringSubmatch(line); match != nil && field != nil {
+			field.value += unescapePO(match[1])
+			continue
+		}
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			finish(i)
+			continue
+		}
+		return nil, fmt.Errorf("unexpected line %d in PO file", i+1)
+	}
+	finish(len(f.lines))
+
+	for _, entry := range f.entries {
+		if len(entry.msgstrs) == 0 {
+			return nil, fmt.Errorf("PO entry %q has no msgstr", entry.id)
+		}
+	}
+	return f, nil
+}
+
+func (f *poFile) values() []string {
+	var values []string
+	for _, entry := range f.entries {
+		if entry.id == "" {
+			continue
+		}
+		values = append(values, entry.id)
+		if entry.plurals {
+			values = append(values, entry.plural)
+		}
+	}
+	return values
+}
+
+// sources returns the source text of every msgstr in the translation. A
+// plural message gets one form for each the target language has, or keeps
+// the catalog's forms if its plural rule is unknown.
+func (f *poFile) sources(opts Options) []localeSource {
+	rule, known := pluralRuleFor(opts.TargetLanguage)
+	var sources []localeSource
+	for _, entry := range f.entries {
+		switch {
+		case entry.id == "":
+			continue
+		case !entry.plurals:
+			sources = append(sources, localeSource{text: entry.id})
+		case known:
+			for k, form := range rule.forms {
+				text := entry.plural
+				if k == rule.singular {
+					text = entry.id
+				}
+				sources = append(sources, localeSource{text: text, form: form})
+			}
+		default:
+			sources = append(sources, localeSource{text: entry.id})
+			for range entry.msgstrs[1:] {
+				sources = append(sources, localeSource{text: entry.plural})
+			}
+		}
+	}
+	return sources
+}
+
+// header returns the header of a catalog in the target language, with its
+// Language and Plural-Forms fields updated.
+func (f *poFile) header(value string, opts Options) string {
+	value = poLanguage.ReplaceAllString(value, "Language: "+strings.ReplaceAll(opts.TargetLanguage, "-", "_"))
+	rule, ok := pluralRuleFor(opts.TargetLanguage)
+	switch {
+	case !ok:
+	case poPluralForms.MatchString(value):
+		value = poPluralForms.ReplaceAllString(value, "Plural-Forms: "+rule.header)
+	case f.hasPlurals():
+		if value != "" && !strings.HasSuffix(value, "\n") {
+			value += "\n"
+		}
+		value += "Plural-Forms: " + rule.header + "\n"
+	}
+	return value
+}
+
+func (f *poFile) hasPlurals() bool {
+	for _, entry := range f.entries {
+		if entry.plurals {
+			return true
+		}
+	}
+	return false
+}
+
+// render takes the translations of sources(opts), in order. The msgstr
+// fields of a plural message are replaced as a whole, since their number
+// may change.
+func (f *poFile) render(values []string, opts Options) (string, error) {
+	replaced := make(map[int][]string)
+	skip := make(map[int]bool)
+	next := 0
+	for _, entry := range f.entries {
+		var msgstrs []string
+		if entry.id == "" {
+			msgstrs = []string{f.header(entry.msgstrs[0].value, opts)}
+		} else {
+			n := 1
+			if entry.plurals {
+				n = len(entry.msgstrs)
+				if rule, ok := pluralRuleFor(opts.TargetLanguage); ok {
+					n = len(rule.forms)
+				}
+			}
+			if next+n > len(values) {
+				return "", fmt.Errorf("missing translation of PO entry %q", entry.id)
+			}
+			msgstrs = values[next : next+n]
+			next += n
+		}
+
+		first := entry.msgstrs[0]
+		wrapped := first.end-first.start > 1
+		var lines []string
+		for k, value := range msgstrs {
+			keyword := first.keyword
+			if entry.plurals {
+				keyword = fmt.Sprintf("msgstr[%d]", k)
+			}
+			lines = append(lines, formatPOField(keyword, value, wrapped, f.newline)...)
+		}
+		replaced[first.start] = lines
+		for _, field := range entry.msgstrs {
+			for line := field.start; line < field.end; line++ {
+				if line != first.start {
+					skip[line] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i, line := range f.lines {
+		if lines, ok := replaced[i]; ok {
+			b.WriteString(strings.Join(lines, ""))
+			continue
+		}
+		if !skip[i] {
+			b.WriteString(line)
+		}
+	}
+	return b.String(), nil
+}
+
+// formatPOField writes a keyword and value, wrapped after each newline in
+// the value when the field was wrapped before.
+func formatPOField(keyword, value string, wrapped bool, newline string) []string {
+	if !wrapped || !strings.Contains(strings.TrimSuffix(value, "\n"), "\n") {
+		return []string{keyword + ` "` + escapePO(value) + `"` + newline}
+	}
+	lines := []string{keyword + ` ""` + newline}
+	for _, piece := range strings.SplitAfter(value, "\n") {
+		if piece != "" {
+			lines = append(lines, `"`+escapePO(piece)+`"`+newline)
+		}
+	}
+	return lines
+}
+
+// shape leaves out how many forms plural messages have, which depends on
+// the language.
+func (f *poFile) shape() string {
+	var b strings.Builder
+	for _, entry := range f.entries {
+		forms := len(entry.msgstrs)
+		if entry.plurals {
+			forms = 0
+		}
+		fmt.Fprintf(&b, "%q %q %t %q %d\n", entry.context, entry.id, entry.plurals, entry.plural, forms)
+	}
+	return b.String()
+}
+
+var (
+	poUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\t`, "\t", `\r`, "\r")
+	poEscaper   = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+)
+
+func unescapePO(s string) string {
+	return poUnescaper.Replace(s)
+}
+
+func escapePO(s string) string {
+	return poEscaper.Replace(s)
+}
@@ -0,0 +1,323 @@
+package translate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"expvar"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lingopaste/backend/internal/cache"
+	"golang.org/x/text/unicode/norm"
+)
+
+var fenceLine = regexp.MustCompile("(?m)^[ \t]*(?:```|~~~)")
+
+func init() {
+	metrics.Set("memory_hit_rate", expvar.Func(func() any {
+		hits, misses := metricValue("memory_hits"), metricValue("memory_misses")
+		if hits+misses == 0 {
+			return 0.0
+		}
+		return float64(hits) / float64(hits+misses)
+	}))
+}
+
+func metricValue(name string) int64 {
+	if v, ok := metrics.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// MemoryStore persists segment translations by key.
+type MemoryStore interface {
+	GetMemorySegments(ctx context.Context, keys []string) (map[string]string, error)
+	PutMemorySegments(ctx context.Context, segments map[string]string) error
+}
+
+// MemoryTranslator is a translation memory: it splits texts into paragraphs
+// and reuses earlier translations of identical paragraphs, sending only the
+// novel ones to the inner translator. Entries are keyed by the normalized
+// source together with everything that shapes its translation (target
+// language, tone, glossary version, ...), so they never leak across
+// settings. Store failures are logged and treated as misses.
+type MemoryTranslator struct {
+	inner Translator
+	store MemoryStore
+	cache *cache.LRUCache
+}
+
+func NewMemoryTranslator(inner Translator, store MemoryStore, cache *cache.LRUCache) *MemoryTranslator {
+	return &MemoryTranslator{
+		inner: inner,
+		store: store,
+		cache: cache,
+	}
+}
+
+func (t *MemoryTranslator) DetectLanguage(ctx context.Context, text string) (string, error) {
+	return t.inner.DetectLanguage(ctx, text)
+}
+
+func (t *MemoryTranslator) Translate(ctx context.Context, text string, opts Options) (string, error) {
+	return t.translate(ctx, text, opts, nil)
+}
+
+func (t *MemoryTranslator) TranslateStream(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error) {
+	return t.translate(ctx, text, opts, onChunk)
+}
+
+// memorySegment is a paragraph of the source. Its translation replaces
+// core; lead and trail whitespace are kept from the source.
+type memorySegment struct {
+	lead, core, trail string
+	key               string
+	translation       string
+	hit               bool
+}
+
+func (t *MemoryTranslator) translate(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error) {
+	segments := t.lookup(ctx, splitParagraphs(text), opts)
+
+	var out strings.Builder
+	emit := func(s string) error {
+		out.WriteString(s)
+		if onChunk == nil || s == "" {
+			return nil
+		}
+		return onChunk(s)
+	}
+
+	learned := make(map[string]string)
+	for i := 0; i < len(segments); {
+		if segments[i].hit || segments[i].core == "" {
+			seg := segments[i]
+			if err := emit(seg.lead + seg.translation + seg.trail); err != nil {
+				return "", err
+			}
+			i++
+			continue
+		}
+
+		// Translate a run of consecutive misses in one request so the
+		// model keeps their context.
+		j := i
+		for j < len(segments) && !segments[j].hit && segments[j].core != "" {
+			j++
+		}
+		run := segments[i:j]
+
+		if err := emit(run[0].lead); err != nil {
+			return "", err
+		}
+		translated, err := t.translateRun(ctx, run, opts, onChunk, &out)
+		if err != nil {
+			return "", err
+		}
+		if err := emit(run[len(run)-1].trail); err != nil {
+			return "", err
+		}
+
+		// A translation whose placeholders differ from the source's is
+		// flagged to the caller of this request, but must not be served
+		// from memory to later ones.
+		aligned := alignRun(run, translated)
+		for _, seg := range run {
+			if translation, ok := aligned[seg.key]; ok && samePlaceholders(seg.core, translation) {
+				learned[seg.key] = translation
+			}
+		}
+		i = j
+	}
+
+	t.save(ctx, learned)
+	return out.String(), nil
+}
+
+// translateRun translates the source spanned by run, from its first core to
+// its last, streaming when a chunk callback is given.
+func (t *MemoryTranslator) translateRun(ctx context.Context, run []memorySegment, opts Options, onChunk func(string) error, out *strings.Builder) (string, error) {
+	var source strings.Builder
+	for i, seg := range run {
+		if i > 0 {
+			source.WriteString(seg.lead)
+		}
+		source.WriteString(seg.core)
+		if i < len(run)-1 {
+			source.WriteString(seg.trail)
+		}
+	}
+
+	var translated string
+	var err error
+	if streamer, ok := t.inner.(StreamingTranslator); ok && onChunk != nil {
+		translated, err = streamer.TranslateStream(ctx, source.String(), opts, onChunk)
+	} else {
+		translated, err = t.inner.Translate(ctx, source.String(), opts)
+		if err == nil && onChunk != nil {
+			err = onChunk(translated)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	out.WriteString(translated)
+	return translated, nil
+}
+
+// alignRun maps each segment of run to its part of the run's translation.
+// If the model merged or split paragraphs there is no reliable alignment
+// and nothing is learned, except for single-paragraph runs.
+func alignRun(run []memorySegment, translated string) map[string]string {
+	if len(run) == 1 {
+		return map[string]string{run[0].key: strings.TrimSpace(translated)}
+	}
+
+	parts := splitParagraphs(translated)
+	if len(parts) != len(run) {
+		return nil
+	}
+	learned := make(map[string]string, len(run))
+	for i, part := range parts {
+		_, core, _ := splitSpace(part)
+		learned[run[i].key] = core
+	}
+	return learned
+}
+
+// lookup resolves the key of each paragraph and fills in known translations
+// from the cache, then the store.
+func (t *MemoryTranslator) lookup(ctx context.Context, paragraphs []string, opts Options) []memorySegment {
+	profile := memoryProfile(TierFromContext(ctx), opts)
+	segments := make([]memorySegment, len(paragraphs))
+	var missing []string
+
+	for i, paragraph := range paragraphs {
+		lead, core, trail := splitSpace(paragraph)
+		segments[i] = memorySegment{lead: lead, core: core, trail: trail}
+		if core == "" {
+			continue
+		}
+		segments[i].key = memoryKey(profile, core)
+		if cached, ok := t.cache.Get(memoryCacheKey(segments[i].key)); ok {
+			segments[i].translation = cached.(string)
+			segments[i].hit = true
+		} else {
+			missing = append(missing, segments[i].key)
+		}
+	}
+
+	if len(missing) > 0 {
+		found, err := t.store.GetMemorySegments(ctx, missing)
+		if err != nil {
+			log.Printf("Error reading translation memory: %v", err)
+		}
+		for i := range segments {
+			// Entries saved before placeholders were checked may be broken.
+			if translation, ok := found[segments[i].key]; ok && !segments[i].hit && samePlaceholders(segments[i].core, translation) {
+				segments[i].translation = translation
+				segments[i].hit = true
+				t.cache.Set(memoryCacheKey(segments[i].key), translation)
+			}
+		}
+	}
+
+	hits, misses := 0, 0
+	for _, seg := range segments {
+		switch {
+		case seg.core == "":
+		case seg.hit:
+			hits++
+		default:
+			misses++
+		}
+	}
+	metrics.Add("memory_hits", int64(hits))
+	metrics.Add("memory_misses", int64(misses))
+	recordMemory(ctx, hits, misses)
+
+	return segments
+}
+
+func (t *MemoryTranslator) save(ctx context.Context, learned map[string]string) {
+	if len(learned) == 0 {
+		return
+	}
+	for key, translation := range learned {
+		t.cache.Set(memoryCacheKey(key), translation)
+	}
+	if err := t.store.PutMemorySegments(context.WithoutCancel(ctx), learned); err != nil {
+		log.Printf("Error writing translation memory: %v", err)
+	}
+}
+
+// splitParagraphs splits text at blank lines, keeping fenced code blocks
+// whole. Concatenating the result reproduces text exactly.
+func splitParagraphs(text string) []string {
+	var paragraphs []string
+	var open strings.Builder
+	for _, piece := range splitAfter(text, paragraphBreak) {
+		open.WriteString(piece)
+		if len(fenceLine.FindAllStringIndex(open.String(), -1))%2 == 0 {
+			paragraphs = append(paragraphs, open.String())
+			open.Reset()
+		}
+	}
+	if open.Len() > 0 {
+		paragraphs = append(paragraphs, open.String())
+	}
+	return paragraphs
+}
+
+// memoryProfile captures every option that changes how a segment is
+// translated. Tiers are kept apart so paid accounts never receive
+// translations made with a free tier model.
+func memoryProfile(tier string, opts Options) string {
+	fields := []string{
+		"v2",
+		tier,
+		opts.TargetLanguage,
+		opts.Tone,
+		opts.Formality,
+		strconv.FormatBool(opts.TranslateCodeComments),
+		strconv.FormatBool(opts.Romanize),
+		opts.GlossaryVersion,
+	}
+	if opts.Format != "" && opts.Format != FormatText {
+		fields = append(fields, "format="+opts.Format)
+	}
+	if opts.PluralForm != "" {
+		fields = append(fields, "plural="+opts.PluralForm)
+	}
+	if tone := opts.CustomTone; tone != nil {
+		temperature := ""
+		if tone.Temperature != nil {
+			temperature = strconv.FormatFloat(float64(*tone.Temperature), 'f', -1, 32)
+		}
+		fields = append(fields, tone.Instruction, temperature, strings.Join(tone.Examples, "\x1f"))
+	}
+	return strings.Join(fields, "\x00")
+}
+
+// memoryKey hashes a segment's normalized source under profile. Only
+// Unicode composition and trailing whitespace on each line are normalized;
+// indentation is significant in code and lists.
+func memoryKey(profile, core string) string {
+	source := norm.NFC.String(strings.ReplaceAll(core, "\r\n", "\n"))
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+
+	sum := sha256.Sum256([]byte(profile + "\x00" + strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func memoryCacheKey(key string) string {
+	return fmt.Sprintf("tm:%s", key)
+}
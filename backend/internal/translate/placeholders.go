@@ -0,0 +1,330 @@
+package translate
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lingopaste/backend/internal/models"
+)
+
+// Placeholder syntaxes of common i18n libraries, matched at the start of
+// the remaining text.
+var (
+	printfVerb  = regexp.MustCompile(`^%(?:\d+\$)?[-+0#']*(?:\d+|\*)?(?:\.(?:\d+|\*))?(?:hh|h|ll|l|L|q|j|z|t)?[diouxXeEfFgGaAcspqvT@%]`)
+	pythonNamed = regexp.MustCompile(`^%\([^()\s]+\)[-+0#]*\d*(?:\.\d+)?[diouxXeEfFgGcrsa]`)
+	rubyNamed   = regexp.MustCompile(`^%\{[^{}\s]+\}|^%<[^<>\s]+>[-+0#]*\d*(?:\.\d+)?[diouxXeEfFgGs]`)
+	mustache    = regexp.MustCompile(`^\{\{\{?[^{}]*\}?\}\}`)
+	shellVar    = regexp.MustCompile(`^\$\{[^{}\s]+\}`)
+	markupTag   = regexp.MustCompile(`^</?[A-Za-z][^<>\n]*>`)
+
+	// ICU MessageFormat: simple arguments such as {name} or {n, number},
+	// and the pieces of plural and select arguments around their messages.
+	icuSimple   = regexp.MustCompile(`^\{\s*[\w.]+\s*(?:,\s*\w+\s*(?:,[^{}]*)?)?\}`)
+	icuComplex  = regexp.MustCompile(`^\{\s*[\w.]+\s*,\s*(?:plural|select|selectordinal)\s*,(?:\s*offset:\s*\d+)?`)
+	icuSelector = regexp.MustCompile(`^\s*(?:=\d+|[\w-]+)\s*\{`)
+	icuEnd      = regexp.MustCompile(`^\s*\}`)
+)
+
+// placeholderScanner finds placeholders in a message, descending into the
+// messages of ICU plural and select arguments, which are translatable.
+type placeholderScanner struct {
+	text  string
+	spans []span
+}
+
+// placeholderSpans returns the placeholders of text in order. The syntax
+// of an ICU plural or select argument is returned piecewise, leaving the
+// messages inside it visible.
+func placeholderSpans(text string) []span {
+	s := &placeholderScanner{text: text}
+	s.message(0, false)
+	return s.spans
+}
+
+func (s *placeholderScanner) add(start, end int) {
+	s.spans = append(s.spans, span{start, end})
+}
+
+// message scans from i to the end of text or, when nested in an ICU
+// argument, to the brace closing the message, whose position it returns.
+func (s *placeholderScanner) message(i int, nested bool) int {
+	for i < len(s.text) {
+		rest := s.text[i:]
+		var patterns []*regexp.Regexp
+		switch rest[0] {
+		case '{':
+			if end, ok := s.complexArgument(i); ok {
+				i = end
+				continue
+			}
+			patterns = []*regexp.Regexp{mustache, icuSimple}
+		case '}':
+			if nested {
+				return i
+			}
+		case '#':
+			if nested {
+				s.add(i, i+1)
+				i++
+				continue
+			}
+		case '%':
+			patterns = []*regexp.Regexp{pythonNamed, rubyNamed, printfVerb}
+		case '$':
+			patterns = []*regexp.Regexp{shellVar}
+		}
+
+		matched := false
+		for _, pattern := range patterns {
+			if loc := pattern.FindStringIndex(rest); loc != nil {
+				s.add(i, i+loc[1])
+				i += loc[1]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			i++
+		}
+	}
+	return i
+}
+
+// complexArgument scans an ICU plural or select argument starting at i.
+// Malformed arguments are left alone, as if they were text.
+func (s *placeholderScanner) complexArgument(i int) (int, bool) {
+	loc := icuComplex.FindStringIndex(s.text[i:])
+	if loc == nil {
+		return 0, false
+	}
+	mark := len(s.spans)
+	s.add(i, i+loc[1])
+	i += loc[1]
+	for {
+		rest := s.text[i:]
+		if loc := icuEnd.FindStringIndex(rest); loc != nil {
+			s.add(i, i+loc[1])
+			return i + loc[1], true
+		}
+		loc := icuSelector.FindStringIndex(rest)
+		if loc == nil {
+			s.spans = s.spans[:mark]
+			return 0, false
+		}
+		s.add(i, i+loc[1])
+		i = s.message(i+loc[1], true)
+		if i >= len(s.text) {
+			s.spans = s.spans[:mark]
+			return 0, false
+		}
+		s.add(i, i+1)
+		i++
+	}
+}
+
+// markupSpans returns the HTML-like tags of text.
+func markupSpans(text string) []span {
+	var spans []span
+	for i := 0; i < len(text); i++ {
+		if text[i] != '<' {
+			continue
+		}
+		if loc := markupTag.FindStringIndex(text[i:]); loc != nil {
+			spans = append(spans, span{i, i + loc[1]})
+			i += loc[1] - 1
+		}
+	}
+	return spans
+}
+
+// mergeSpans sorts spans, drops those overlapping an earlier one and joins
+// adjacent ones, so that a run of syntax becomes a single token.
+func mergeSpans(spans []span) []span {
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var merged []span
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s.start <= merged[n-1].end {
+			if s.start == merged[n-1].end {
+				merged[n-1].end = s.end
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// placeholderCounts returns how often each placeholder occurs in text.
+func placeholderCounts(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, s := range placeholderSpans(text) {
+		counts[text[s.start:s.end]]++
+	}
+	return counts
+}
+
+// samePlaceholders reports whether a and b have the same placeholders, in
+// any order. A broken ICU argument no longer scans as one, so this also
+// catches translations that mangled its syntax.
+func samePlaceholders(a, b string) bool {
+	countsA, countsB := placeholderCounts(a), placeholderCounts(b)
+	if len(countsA) != len(countsB) {
+		return false
+	}
+	for placeholder, n := range countsA {
+		if countsB[placeholder] != n {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckPlaceholders reports every placeholder that occurs a different number
+// of times in translated than in source.
+func CheckPlaceholders(source, translated string) []models.PlaceholderMismatch {
+	expected, found := placeholderCounts(source), placeholderCounts(translated)
+	var mismatches []models.PlaceholderMismatch
+	for placeholder, n := range expected {
+		if found[placeholder] != n {
+			mismatches = append(mismatches, models.PlaceholderMismatch{Placeholder: placeholder, Expected: n, Found: found[placeholder]})
+		}
+	}
+	for placeholder, n := range found {
+		if _, ok := expected[placeholder]; !ok {
+			mismatches = append(mismatches, models.PlaceholderMismatch{Placeholder: placeholder, Found: n})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool { return mismatches[i].Placeholder < mismatches[j].Placeholder })
+	return mismatches
+}
+
+var variableToken = regexp.MustCompile(`⟦P(\d+)⟧`)
+
+// maskPlaceholders replaces the placeholders of text with ⟦P0⟧ tokens.
+func maskPlaceholders(text string) *maskedText {
+	m := &maskedText{kind: "P", token: variableToken}
+	var b strings.Builder
+	last := 0
+	for _, s := range mergeSpans(placeholderSpans(text)) {
+		b.WriteString(text[last:s.start])
+		b.WriteString(placeholderOpen + m.kind + strconv.Itoa(len(m.originals)) + placeholderClose)
+		m.originals = append(m.originals, text[s.start:s.end])
+		last = s.end
+	}
+	b.WriteString(text[last:])
+	m.text = b.String()
+	return m
+}
+
+// PlaceholderTranslator protects the placeholders and variables of i18n
+// strings and templates, such as {{name}}, %s, ${VAR} or ICU arguments, by
+// masking them before translation. A translation whose placeholders differ
+// from the source's is retried; if it still differs, the best attempt is
+// returned so the caller can flag it with CheckPlaceholders.
+type PlaceholderTranslator struct {
+	inner Translator
+}
+
+func NewPlaceholderTranslator(inner Translator) *PlaceholderTranslator {
+	return &PlaceholderTranslator{inner: inner}
+}
+
+func (t *PlaceholderTranslator) DetectLanguage(ctx context.Context, text string) (string, error) {
+	m := maskPlaceholders(text)
+	if !m.hasProse() {
+		return t.inner.DetectLanguage(ctx, text)
+	}
+	return t.inner.DetectLanguage(ctx, variableToken.ReplaceAllString(m.text, " "))
+}
+
+func (t *PlaceholderTranslator) Translate(ctx context.Context, text string, opts Options) (string, error) {
+	m := maskPlaceholders(text)
+	if len(m.originals) == 0 {
+		return t.inner.Translate(ctx, text, opts)
+	}
+	if !m.hasProse() {
+		return text, nil
+	}
+
+	var best string
+	for attempt := 0; attempt <= maxPlaceholderRetries; attempt++ {
+		translated, err := t.inner.Translate(ctx, m.text, opts)
+		if err != nil {
+			return "", err
+		}
+		restored, ok := m.check(text, translated)
+		if ok {
+			return restored, nil
+		}
+		if attempt == 0 || len(CheckPlaceholders(text, restored)) < len(CheckPlaceholders(text, best)) {
+			best = restored
+		}
+	}
+
+	metrics.Add("placeholder_mismatches", 1)
+	return best, nil
+}
+
+// TranslateStream restores placeholders as chunks arrive, holding back any
+// token that is split across chunks. Streamed output cannot be retried, so a
+// mismatch is left for the caller to flag.
+func (t *PlaceholderTranslator) TranslateStream(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error) {
+	streamer, ok := t.inner.(StreamingTranslator)
+	m := maskPlaceholders(text)
+	if ok && len(m.originals) == 0 {
+		return streamer.TranslateStream(ctx, text, opts, onChunk)
+	}
+	if !ok || !m.hasProse() {
+		translated, err := t.Translate(ctx, text, opts)
+		if err != nil {
+			return "", err
+		}
+		return translated, onChunk(translated)
+	}
+
+	var pending strings.Builder
+	translated, err := streamer.TranslateStream(ctx, m.text, opts, func(chunk string) error {
+		pending.WriteString(chunk)
+		buffered := pending.String()
+
+		ready := buffered
+		if open := strings.LastIndex(buffered, placeholderOpen); open >= 0 && !strings.Contains(buffered[open:], placeholderClose) {
+			ready = buffered[:open]
+		}
+		pending.Reset()
+		pending.WriteString(buffered[len(ready):])
+
+		if ready == "" {
+			return nil
+		}
+		return onChunk(m.expand(ready))
+	})
+	if err != nil {
+		return "", err
+	}
+	if pending.Len() > 0 {
+		if err := onChunk(m.expand(pending.String())); err != nil {
+			return "", err
+		}
+	}
+
+	restored, ok := m.check(text, translated)
+	if !ok {
+		metrics.Add("placeholder_mismatches", 1)
+	}
+	return restored, nil
+}
+
+// check restores a translation of the masked source and reports whether it
+// has the same placeholders as the source. Tokens the model made up are
+// dropped rather than shown.
+func (m *maskedText) check(source, translated string) (string, bool) {
+	restored, err := m.restore(translated)
+	if err != nil {
+		return m.token.ReplaceAllString(m.expand(translated), ""), false
+	}
+	return restored, samePlaceholders(source, restored)
+}
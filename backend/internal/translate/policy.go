@@ -0,0 +1,56 @@
+package translate
+
+import "context"
+
+// Account tiers.
+const (
+	TierFree = "free"
+	TierPaid = "paid"
+)
+
+// Operations a model is chosen for.
+const (
+	OperationDetect    = "detect"
+	OperationTranslate = "translate"
+)
+
+// ModelKey selects a model by tier and operation; "*" matches any.
+type ModelKey struct {
+	Tier      string
+	Operation string
+}
+
+// ModelPolicy maps tiers and operations to models.
+type ModelPolicy map[ModelKey]string
+
+// Model returns the model for tier and operation, preferring exact matches
+// over wildcards, or fallback if no rule applies.
+func (p ModelPolicy) Model(tier, operation, fallback string) string {
+	for _, key := range []ModelKey{
+		{tier, operation},
+		{"*", operation},
+		{tier, "*"},
+		{"*", "*"},
+	} {
+		if model, ok := p[key]; ok {
+			return model
+		}
+	}
+	return fallback
+}
+
+type tierKey struct{}
+
+// WithTier returns a context whose provider calls are made for an account
+// of the given tier.
+func WithTier(ctx context.Context, tier string) context.Context {
+	return context.WithValue(ctx, tierKey{}, tier)
+}
+
+// TierFromContext returns the tier set by WithTier, defaulting to free.
+func TierFromContext(ctx context.Context) string {
+	if tier, ok := ctx.Value(tierKey{}).(string); ok {
+		return tier
+	}
+	return TierFree
+}
@@ -0,0 +1,416 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// metrics is published at /api/admin/vars under "translate".
+var metrics = expvar.NewMap("translate")
+
+// ErrCircuitOpen is returned without calling the provider while the circuit
+// breaker considers it unhealthy.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// UnavailableError reports that the provider is unhealthy. RetryAfter is a
+// hint for clients on when to try again.
+type UnavailableError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("translation provider unavailable: %v", e.Err)
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorClass tells callers whether a provider error is worth retrying.
+type ErrorClass int
+
+const (
+	// ErrorPermanent covers invalid requests, authentication failures and
+	// anything else that will fail the same way again.
+	ErrorPermanent ErrorClass = iota
+	// ErrorTransient covers rate limiting, server errors, timeouts and
+	// network failures.
+	ErrorTransient
+	// ErrorCanceled means the caller gave up; it says nothing about the
+	// provider's health.
+	ErrorCanceled
+)
+
+// Classify sorts a provider error into an ErrorClass.
+func Classify(err error) ErrorClass {
+	if errors.Is(err, context.Canceled) {
+		return ErrorCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorTransient
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return classifyStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return classifyStatus(reqErr.HTTPStatusCode)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorTransient
+	}
+	return ErrorPermanent
+}
+
+func classifyStatus(status int) ErrorClass {
+	switch {
+	case status == 0,
+		status == http.StatusRequestTimeout,
+		status == http.StatusTooManyRequests,
+		status >= 500:
+		return ErrorTransient
+	default:
+		return ErrorPermanent
+	}
+}
+
+type retryHintKey struct{}
+
+// retryHint receives the Retry-After header of a failed provider response.
+// It travels in the request context, which is the only thing the OpenAI
+// client hands to its HTTP transport.
+type retryHint struct {
+	mu    sync.Mutex
+	after time.Duration
+}
+
+func (h *retryHint) set(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.after = d
+}
+
+func (h *retryHint) get() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.after
+}
+
+// retryAfterTransport copies Retry-After headers of failed responses into
+// the retryHint of the request's context.
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func newProviderHTTPClient() *http.Client {
+	return &http.Client{Transport: &retryAfterTransport{base: http.DefaultTransport}}
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 400 {
+		return resp, err
+	}
+	if hint, ok := req.Context().Value(retryHintKey{}).(*retryHint); ok {
+		hint.set(parseRetryAfter(resp.Header))
+	}
+	return resp, err
+}
+
+// parseRetryAfter understands delay-seconds and HTTP-date forms, plus the
+// millisecond variant some OpenAI-compatible servers send.
+func parseRetryAfter(header http.Header) time.Duration {
+	if ms := header.Get("Retry-After-Ms"); ms != "" {
+		if n, err := strconv.ParseFloat(ms, 64); err == nil && n > 0 {
+			return time.Duration(n * float64(time.Millisecond))
+		}
+	}
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker opens after threshold consecutive transient failures and
+// rejects calls for cooldown. It then lets a single probe through: success
+// closes it again, failure reopens it.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	name      string
+	threshold int
+	cooldown  time.Duration
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+// NewCircuitBreaker creates a breaker whose state is published as
+// "breaker_state.<name>".
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	b := &CircuitBreaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+	metrics.Set("breaker_state."+name, expvar.Func(func() any { return b.State() }))
+	return b
+}
+
+// Allow reports whether a call may proceed and, if not, how long the caller
+// should wait.
+func (b *CircuitBreaker) Allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		remaining := b.cooldown - time.Since(b.openedAt)
+		if remaining > 0 {
+			return remaining, false
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return 0, true
+	case breakerHalfOpen:
+		if b.probing {
+			return time.Second, false
+		}
+		b.probing = true
+		return 0, true
+	default:
+		return 0, true
+	}
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = breakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		if b.state != breakerOpen {
+			metrics.Add("breaker_opens."+b.name, 1)
+		}
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Release gives up a half-open probe slot without judging the provider,
+// for calls that ended in a permanent error or cancellation.
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state.String()
+}
+
+// ResilientTranslator retries transient provider failures with exponential
+// backoff, bounds each call with a timeout and stops calling the provider
+// altogether while its circuit breaker is open.
+type ResilientTranslator struct {
+	inner       Translator
+	breaker     *CircuitBreaker
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	callTimeout time.Duration
+}
+
+func NewResilientTranslator(inner Translator, breaker *CircuitBreaker, maxAttempts int, baseDelay, maxDelay, callTimeout time.Duration) *ResilientTranslator {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &ResilientTranslator{
+		inner:       inner,
+		breaker:     breaker,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		maxDelay:    maxDelay,
+		callTimeout: callTimeout,
+	}
+}
+
+func (t *ResilientTranslator) DetectLanguage(ctx context.Context, text string) (string, error) {
+	var language string
+	err := t.do(ctx, t.callTimeout, func(ctx context.Context) error {
+		var err error
+		language, err = t.inner.DetectLanguage(ctx, text)
+		return err
+	}, nil)
+	return language, err
+}
+
+func (t *ResilientTranslator) Translate(ctx context.Context, text string, opts Options) (string, error) {
+	var translated string
+	err := t.do(ctx, t.callTimeout, func(ctx context.Context) error {
+		var err error
+		translated, err = t.inner.Translate(ctx, text, opts)
+		return err
+	}, nil)
+	return translated, err
+}
+
+// TranslateStream retries only until the first chunk has been delivered;
+// after that a failure is final. Streams are not bound by the per-call
+// timeout since their length is what streaming is for.
+func (t *ResilientTranslator) TranslateStream(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error) {
+	streamer, ok := t.inner.(StreamingTranslator)
+	if !ok {
+		translated, err := t.Translate(ctx, text, opts)
+		if err != nil {
+			return "", err
+		}
+		return translated, onChunk(translated)
+	}
+
+	emitted := false
+	var translated string
+	err := t.do(ctx, 0, func(ctx context.Context) error {
+		var err error
+		translated, err = streamer.TranslateStream(ctx, text, opts, func(chunk string) error {
+			emitted = true
+			return onChunk(chunk)
+		})
+		return err
+	}, func() bool { return !emitted })
+	return translated, err
+}
+
+// do runs call until it succeeds, fails permanently or runs out of attempts.
+// canRetry, when set, can veto further attempts.
+func (t *ResilientTranslator) do(ctx context.Context, timeout time.Duration, call func(context.Context) error, canRetry func() bool) error {
+	var lastErr error
+	var lastHint time.Duration
+
+	for attempt := 1; ; attempt++ {
+		if wait, ok := t.breaker.Allow(); !ok {
+			metrics.Add("breaker_rejections."+t.breaker.name, 1)
+			return &UnavailableError{RetryAfter: wait, Err: ErrCircuitOpen}
+		}
+
+		hint := &retryHint{}
+		callCtx := context.WithValue(ctx, retryHintKey{}, hint)
+		cancel := func() {}
+		if timeout > 0 {
+			callCtx, cancel = context.WithTimeout(callCtx, timeout)
+		}
+		err := call(callCtx)
+		cancel()
+
+		if err == nil {
+			t.breaker.Success()
+			return nil
+		}
+		if ctx.Err() != nil || Classify(err) != ErrorTransient {
+			t.breaker.Release()
+			return err
+		}
+
+		t.breaker.Failure()
+		metrics.Add("provider_failures", 1)
+		lastErr, lastHint = err, hint.get()
+
+		if attempt >= t.maxAttempts || (canRetry != nil && !canRetry()) {
+			break
+		}
+		delay, ok := t.backoff(ctx, attempt, lastHint)
+		if !ok {
+			break
+		}
+
+		metrics.Add("provider_retries", 1)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	retryAfter := lastHint
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	return &UnavailableError{RetryAfter: retryAfter, Err: lastErr}
+}
+
+// backoff returns how long to wait before the next attempt: the provider's
+// Retry-After if it sent one, otherwise exponential backoff with jitter. It
+// reports false when the wait would exceed maxDelay or the caller's deadline.
+func (t *ResilientTranslator) backoff(ctx context.Context, attempt int, hint time.Duration) (time.Duration, bool) {
+	delay := hint
+	if delay == 0 {
+		delay = t.baseDelay << (attempt - 1)
+		if delay > t.maxDelay || delay <= 0 {
+			delay = t.maxDelay
+		}
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+	if delay > t.maxDelay {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return 0, false
+	}
+	return delay, true
+}
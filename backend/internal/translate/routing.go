@@ -0,0 +1,177 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Backend is a named translator taking part in routing.
+type Backend struct {
+	Name       string
+	Translator Translator
+}
+
+// Route prefers Backends for translations from Source to Target in Tone.
+// Empty fields and "*" match anything, and a base language matches all of
+// its regional tags.
+type Route struct {
+	Source   string
+	Target   string
+	Tone     string
+	Backends []string
+}
+
+func (r Route) matches(opts Options) bool {
+	return matchLanguage(r.Source, opts.SourceLanguage) &&
+		matchLanguage(r.Target, opts.TargetLanguage) &&
+		(r.Tone == "" || r.Tone == "*" || r.Tone == opts.Tone)
+}
+
+func matchLanguage(pattern, tag string) bool {
+	return pattern == "" || pattern == "*" || pattern == tag || pattern == BaseLanguage(tag)
+}
+
+// RoutingTranslator sends each call to the backends preferred by the first
+// matching route, then falls back to the remaining backends in order. The
+// name of the backend that produced a translation is recorded on the
+// context's Meter.
+type RoutingTranslator struct {
+	backends []Backend
+	routes   []Route
+}
+
+func NewRoutingTranslator(backends []Backend, routes []Route) (*RoutingTranslator, error) {
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("no translator backends configured")
+	}
+	names := make(map[string]bool, len(backends))
+	for _, backend := range backends {
+		names[backend.Name] = true
+	}
+	for _, route := range routes {
+		for _, name := range route.Backends {
+			if !names[name] {
+				return nil, fmt.Errorf("route refers to unknown backend %q", name)
+			}
+		}
+	}
+
+	return &RoutingTranslator{
+		backends: backends,
+		routes:   routes,
+	}, nil
+}
+
+// chain returns the backends to try for opts, most preferred first.
+func (t *RoutingTranslator) chain(opts Options) []Backend {
+	var preferred []string
+	for _, route := range t.routes {
+		if route.matches(opts) {
+			preferred = route.Backends
+			break
+		}
+	}
+	if len(preferred) == 0 {
+		return t.backends
+	}
+
+	chain := make([]Backend, 0, len(t.backends))
+	used := make(map[string]bool, len(t.backends))
+	for _, name := range preferred {
+		for _, backend := range t.backends {
+			if backend.Name == name && !used[name] {
+				chain = append(chain, backend)
+				used[name] = true
+			}
+		}
+	}
+	for _, backend := range t.backends {
+		if !used[backend.Name] {
+			chain = append(chain, backend)
+		}
+	}
+	return chain
+}
+
+func (t *RoutingTranslator) DetectLanguage(ctx context.Context, text string) (string, error) {
+	var language string
+	err := t.try(ctx, t.backends, func(backend Backend) error {
+		var err error
+		language, err = backend.Translator.DetectLanguage(ctx, text)
+		return err
+	})
+	return language, err
+}
+
+func (t *RoutingTranslator) Translate(ctx context.Context, text string, opts Options) (string, error) {
+	var translated string
+	err := t.try(ctx, t.chain(opts), func(backend Backend) error {
+		var err error
+		translated, err = backend.Translator.Translate(ctx, text, opts)
+		return err
+	})
+	return translated, err
+}
+
+// TranslateStream only falls back while nothing has been emitted; once a
+// backend has started streaming its failure is final.
+func (t *RoutingTranslator) TranslateStream(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error) {
+	emitted := false
+	emit := func(chunk string) error {
+		emitted = true
+		return onChunk(chunk)
+	}
+
+	var translated string
+	err := t.try(ctx, t.chain(opts), func(backend Backend) error {
+		var err error
+		if streamer, ok := backend.Translator.(StreamingTranslator); ok {
+			translated, err = streamer.TranslateStream(ctx, text, opts, emit)
+		} else {
+			translated, err = backend.Translator.Translate(ctx, text, opts)
+			if err == nil {
+				err = emit(translated)
+			}
+		}
+		if err != nil && emitted {
+			return errStopFallback{err}
+		}
+		return err
+	})
+	return translated, err
+}
+
+// errStopFallback wraps an error that must not be retried on another backend.
+type errStopFallback struct {
+	err error
+}
+
+func (e errStopFallback) Error() string { return e.err.Error() }
+func (e errStopFallback) Unwrap() error { return e.err }
+
+// try calls each backend of chain until one succeeds. The last error is
+// returned if all fail.
+func (t *RoutingTranslator) try(ctx context.Context, chain []Backend, call func(Backend) error) error {
+	var err error
+	for i, backend := range chain {
+		err = call(backend)
+		if err == nil {
+			recordBackend(ctx, backend.Name)
+			return nil
+		}
+
+		var stop errStopFallback
+		if errors.As(err, &stop) {
+			return stop.err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		if i < len(chain)-1 {
+			log.Printf("Translator backend %s failed, falling back to %s: %v", backend.Name, chain[i+1].Name, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+package translate
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	paragraphBreak = regexp.MustCompile(`\n[ \t]*\n\s*`)
+	sentenceBreak  = regexp.MustCompile(`[.!?]+["'”’)\]]*\s+|[。！？]+\s*`)
+	wordBreak      = regexp.MustCompile(`\s+`)
+)
+
+// EstimateTokens approximates how many model tokens text consumes. ASCII
+// averages roughly four characters per token; other scripts (CJK in
+// particular) are counted as one token per character to stay conservative.
+func EstimateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// Segment splits text into pieces of at most maxTokens estimated tokens,
+// preferring paragraph boundaries, then sentence boundaries, then whitespace.
+// Separators stay attached to the preceding piece, so concatenating the
+// result always reproduces text exactly.
+func Segment(text string, maxTokens int) []string {
+	if maxTokens <= 0 || EstimateTokens(text) <= maxTokens {
+		return []string{text}
+	}
+
+	var segments []string
+	var current strings.Builder
+	currentTokens := 0
+
+	for _, unit := range splitUnits(text, maxTokens) {
+		tokens := EstimateTokens(unit)
+		if currentTokens > 0 && currentTokens+tokens > maxTokens {
+			segments = append(segments, current.String())
+			current.Reset()
+			currentTokens = 0
+		}
+		current.WriteString(unit)
+		currentTokens += tokens
+	}
+	if current.Len() > 0 {
+		segments = append(segments, current.String())
+	}
+
+	return segments
+}
+
+// splitUnits breaks text into the largest natural units that each fit
+// within maxTokens.
+func splitUnits(text string, maxTokens int) []string {
+	var units []string
+	for _, paragraph := range splitAfter(text, paragraphBreak) {
+		if EstimateTokens(paragraph) <= maxTokens {
+			units = append(units, paragraph)
+			continue
+		}
+		for _, sentence := range splitAfter(paragraph, sentenceBreak) {
+			if EstimateTokens(sentence) <= maxTokens {
+				units = append(units, sentence)
+				continue
+			}
+			for _, word := range splitAfter(sentence, wordBreak) {
+				if EstimateTokens(word) <= maxTokens {
+					units = append(units, word)
+					continue
+				}
+				units = append(units, splitRunes(word, maxTokens)...)
+			}
+		}
+	}
+	return units
+}
+
+// splitAfter cuts text after every match of re, keeping the separators.
+func splitAfter(text string, re *regexp.Regexp) []string {
+	var pieces []string
+	start := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		if loc[1] == start {
+			continue
+		}
+		pieces = append(pieces, text[start:loc[1]])
+		start = loc[1]
+	}
+	if start < len(text) {
+		pieces = append(pieces, text[start:])
+	}
+	return pieces
+}
+
+// splitRunes is the last resort for runs without any whitespace, such as
+// long CJK passages or minified data.
+func splitRunes(text string, maxTokens int) []string {
+	var pieces []string
+	for len(text) > 0 {
+		end, ascii, other := 0, 0, 0
+		for i, r := range text {
+			a, o := ascii, other
+			if r < utf8.RuneSelf {
+				a++
+			} else {
+				o++
+			}
+			if (a+3)/4+o > maxTokens && end > 0 {
+				break
+			}
+			ascii, other = a, o
+			end = i + utf8.RuneLen(r)
+		}
+		pieces = append(pieces, text[:end])
+		text = text[end:]
+	}
+	return pieces
+}
+
+// splitSpace separates leading and trailing whitespace from s.
+func splitSpace(s string) (lead, core, trail string) {
+	core = strings.TrimLeftFunc(s, unicode.IsSpace)
+	lead = s[:len(s)-len(core)]
+	trimmed := strings.TrimRightFunc(core, unicode.IsSpace)
+	trail = core[len(trimmed):]
+	return lead, trimmed, trail
+}
@@ -0,0 +1,235 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// segmentBatch is how many segments are sent together. Batches are
+// separated by blank lines, so ChunkedTranslator translates them in
+// parallel, while the segments within a batch give each other context.
+const segmentBatch = 30
+
+var (
+	segmentMarker = regexp.MustCompile(`⟦S(\d+)⟧[ \t]*`)
+	tagToken      = regexp.MustCompile(`⟦T(\d+)⟧`)
+)
+
+// maskedSegment is a segment with the spans that must survive translation
+// verbatim, such as styling tags or placeholders, replaced by ⟦Tn⟧ tokens.
+type maskedSegment struct {
+	text string
+	tags []string
+}
+
+// mask replaces spans of text, which must be ordered and disjoint.
+func mask(text string, spans []span) maskedSegment {
+	var b strings.Builder
+	m := maskedSegment{tags: make([]string, len(spans))}
+	last := 0
+	for i, s := range spans {
+		m.tags[i] = text[s.start:s.end]
+		b.WriteString(text[last:s.start])
+		b.WriteString(placeholderOpen + "T" + strconv.Itoa(i) + placeholderClose)
+		last = s.end
+	}
+	b.WriteString(text[last:])
+	m.text = b.String()
+	return m
+}
+
+// maskPattern masks every match of pattern in text.
+func maskPattern(text string, pattern *regexp.Regexp) maskedSegment {
+	var spans []span
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		spans = append(spans, span{loc[0], loc[1]})
+	}
+	return mask(text, spans)
+}
+
+// restore puts the masked spans back into a translated segment, which must
+// contain each token exactly once.
+func (m maskedSegment) restore(translated string) (string, bool) {
+	seen := make([]bool, len(m.tags))
+	for _, match := range tagToken.FindAllStringSubmatch(translated, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n >= len(m.tags) || seen[n] {
+			return "", false
+		}
+		seen[n] = true
+	}
+	for _, ok := range seen {
+		if !ok {
+			return "", false
+		}
+	}
+	return tagToken.ReplaceAllStringFunc(translated, func(token string) string {
+		n, _ := strconv.Atoi(tagToken.FindStringSubmatch(token)[1])
+		return m.tags[n]
+	}), true
+}
+
+// markSegments numbers segments from first+1 with ⟦Sn⟧ markers, one per
+// line, in paragraphs of segmentBatch.
+func markSegments(segments []maskedSegment, first int) string {
+	var b strings.Builder
+	for i, segment := range segments {
+		if i > 0 {
+			if i%segmentBatch == 0 {
+				b.WriteString("\n\n")
+			} else {
+				b.WriteString("\n")
+			}
+		}
+		fmt.Fprintf(&b, "%sS%d%s %s", placeholderOpen, first+i+1, placeholderClose, segment.text)
+	}
+	return b.String()
+}
+
+// translatedSegment is the text following a segment marker in a
+// translation.
+type translatedSegment struct {
+	n    int
+	text string
+}
+
+// splitSegments returns the text following each segment marker in
+// translated, in order.
+func splitSegments(translated string) []translatedSegment {
+	var segments []translatedSegment
+	matches := segmentMarker.FindAllStringSubmatchIndex(translated, -1)
+	for i, loc := range matches {
+		n, err := strconv.Atoi(translated[loc[2]:loc[3]])
+		if err != nil {
+			continue
+		}
+		end := len(translated)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		segments = append(segments, translatedSegment{n: n, text: strings.TrimSpace(translated[loc[1]:end])})
+	}
+	return segments
+}
+
+// segmentJob translates the pieces of a structured document that are
+// prose, each on its own, while the caller keeps the structure.
+type segmentJob struct {
+	segments []maskedSegment
+	// masked holds the translations as returned, with tags still masked;
+	// results holds them restored.
+	masked  []string
+	results []string
+	done    []bool
+	// valid, if set, rejects translations that are unusable for reasons
+	// other than lost tags.
+	valid func(i int, masked, restored string) bool
+	// progress, if set, streams the translation and is called whenever
+	// more segments are done.
+	progress func() error
+}
+
+func newSegmentJob(segments []maskedSegment) *segmentJob {
+	return &segmentJob{
+		segments: segments,
+		masked:   make([]string, len(segments)),
+		results:  make([]string, len(segments)),
+		done:     make([]bool, len(segments)),
+	}
+}
+
+// accept stores the translation of segment i if its tags survived.
+func (j *segmentJob) accept(i int, translated string) bool {
+	restored, ok := j.segments[i].restore(translated)
+	if ok && strings.TrimSpace(restored) != "" && (j.valid == nil || j.valid(i, translated, restored)) {
+		j.masked[i], j.results[i], j.done[i] = translated, restored, true
+	}
+	return j.done[i]
+}
+
+// acceptAll stores the segments of a marked translation whose last marker
+// should be ⟦S{last}⟧. A segment is only taken when the marker after it is
+// the next one, since a lost marker merges two segments into the first.
+func (j *segmentJob) acceptAll(translated string, last int) {
+	segments := splitSegments(translated)
+	for k, segment := range segments {
+		if segment.n < 1 || segment.n > len(j.segments) || j.done[segment.n-1] {
+			continue
+		}
+		if k+1 < len(segments) && segments[k+1].n == segment.n+1 || k+1 == len(segments) && segment.n == last {
+			j.accept(segment.n-1, segment.text)
+		}
+	}
+}
+
+// translateSegments translates every segment of j. All segments are marked
+// and sent in one request to the inner translator; any segment whose
+// marker or tags did not survive is translated again on its own.
+func (t *FormatTranslator) translateSegments(ctx context.Context, j *segmentJob, opts Options) error {
+	if len(j.segments) == 0 {
+		return nil
+	}
+
+	translated, err := t.translateMarked(ctx, j, opts)
+	if err != nil {
+		return err
+	}
+	j.acceptAll(translated, len(j.segments))
+
+	for i := range j.segments {
+		for attempt := 0; !j.done[i] && attempt <= maxPlaceholderRetries; attempt++ {
+			translated, err := t.inner.Translate(ctx, markSegments(j.segments[i:i+1], i), opts)
+			if err != nil {
+				return err
+			}
+			j.acceptAll(translated, i+1)
+		}
+		if !j.done[i] {
+			return fmt.Errorf("%w: segment %d lost its marker or tags", ErrStructureMismatch, i+1)
+		}
+	}
+	return nil
+}
+
+// translateMarked translates the marked segments. When streaming, each
+// segment is accepted as soon as the marker of the next one arrives, until
+// the first one that cannot be.
+func (t *FormatTranslator) translateMarked(ctx context.Context, j *segmentJob, opts Options) (string, error) {
+	marked := markSegments(j.segments, 0)
+	streamer, ok := t.inner.(StreamingTranslator)
+	if j.progress == nil || !ok {
+		return t.inner.Translate(ctx, marked, opts)
+	}
+
+	var buffered strings.Builder
+	next, scanFrom, derailed := 0, 0, false
+	return streamer.TranslateStream(ctx, marked, opts, func(chunk string) error {
+		buffered.WriteString(chunk)
+		if derailed {
+			return nil
+		}
+		text := buffered.String()
+		for {
+			matches := segmentMarker.FindAllStringSubmatchIndex(text[scanFrom:], 2)
+			if len(matches) < 2 {
+				break
+			}
+			current := text[scanFrom+matches[0][2] : scanFrom+matches[0][3]]
+			following := text[scanFrom+matches[1][2] : scanFrom+matches[1][3]]
+			if current != strconv.Itoa(next+1) || following != strconv.Itoa(next+2) {
+				derailed = true
+				break
+			}
+			if !j.accept(next, strings.TrimSpace(text[scanFrom+matches[0][1]:scanFrom+matches[1][0]])) {
+				derailed = true
+				break
+			}
+			next++
+			scanFrom += matches[1][0]
+		}
+		return j.progress()
+	})
+}
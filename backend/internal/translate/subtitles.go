@@ -0,0 +1,154 @@
+package translate
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+var (
+	srtStart  = regexp.MustCompile(`^\s*\d+[ \t]*\r?\n[ \t]*\d{1,2}:\d{2}:\d{2}[,.]\d{1,3}[ \t]+-->[ \t]+\d{1,2}:\d{2}:\d{2}[,.]\d{1,3}`)
+	vttHeader = regexp.MustCompile(`^WEBVTT(?:[ \t]|\r?\n|$)`)
+
+	// subtitleBreak separates cues and other blocks.
+	subtitleBreak = regexp.MustCompile(`\r?\n(?:[ \t]*\r?\n)+`)
+	// subtitleTag matches HTML-like styling, voice and timestamp tags
+	// and SSA overrides such as {\an8}.
+	subtitleTag = regexp.MustCompile(`<[^<>\n]*>|\{\\[^{}\n]*\}`)
+)
+
+func isWebVTT(text string) bool {
+	return vttHeader.MatchString(text)
+}
+
+func isSRT(text string) bool {
+	return srtStart.MatchString(text)
+}
+
+// subtitleBlock is a cue or another block of a subtitle file, such as the
+// WebVTT header or a NOTE. Only the payload of a cue is ever translated;
+// everything else is reproduced byte for byte.
+type subtitleBlock struct {
+	// header holds the identifier and timing lines of a cue, or all of a
+	// block that is not a cue.
+	header string
+	// payload is the cue text, with "\n" line breaks.
+	payload string
+	// trail is the separator after the block.
+	trail string
+	// cue is the index of the cue in the file, or -1.
+	cue int
+}
+
+type subtitleFile struct {
+	blocks  []subtitleBlock
+	newline string
+	// cues lists the indexes of blocks with a payload.
+	cues []int
+}
+
+func parseSubtitles(text string) *subtitleFile {
+	f := &subtitleFile{newline: "\n"}
+	if strings.Contains(text, "\r\n") {
+		f.newline = "\r\n"
+	}
+
+	start := 0
+	for _, loc := range subtitleBreak.FindAllStringIndex(text, -1) {
+		f.addBlock(text[start:loc[0]], text[loc[0]:loc[1]])
+		start = loc[1]
+	}
+	if start < len(text) {
+		f.addBlock(text[start:], "")
+	}
+	return f
+}
+
+func (f *subtitleFile) addBlock(block, trail string) {
+	b := subtitleBlock{header: block, trail: trail, cue: -1}
+	if timing := strings.Index(block, "-->"); timing >= 0 {
+		if end := strings.IndexByte(block[timing:], '\n'); end >= 0 {
+			// Trailing line breaks, such as the one ending the file, are
+			// kept out of the payload.
+			body := block[timing+end+1:]
+			payload := strings.TrimRight(body, " \t\r\n")
+			b.header = block[:timing+end+1]
+			b.payload = strings.ReplaceAll(payload, "\r\n", "\n")
+			b.trail = body[len(payload):] + trail
+		}
+	}
+	if b.payload != "" {
+		b.cue = len(f.cues)
+		f.cues = append(f.cues, len(f.blocks))
+	} else {
+		b.header, b.trail = block, trail
+	}
+	f.blocks = append(f.blocks, b)
+}
+
+// render returns block i with its payload replaced.
+func (f *subtitleFile) render(i int, payload string) string {
+	b := f.blocks[i]
+	if b.payload == "" {
+		return b.header + b.trail
+	}
+	return b.header + strings.ReplaceAll(payload, "\n", f.newline) + b.trail
+}
+
+// subtitleText returns the cue text of a subtitle file without tags.
+func subtitleText(text string) string {
+	f := parseSubtitles(text)
+	payloads := make([]string, len(f.cues))
+	for i, block := range f.cues {
+		payloads[i] = subtitleTag.ReplaceAllString(f.blocks[block].payload, "")
+	}
+	return strings.Join(payloads, "\n\n")
+}
+
+// translateSubtitles translates only the cue text of an SRT or WebVTT
+// file, with styling tags masked. When streaming, blocks are emitted as
+// soon as they and every block before them are done.
+func (t *FormatTranslator) translateSubtitles(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error) {
+	file := parseSubtitles(text)
+	cues := make([]maskedSegment, len(file.cues))
+	for i, block := range file.cues {
+		cues[i] = maskPattern(file.blocks[block].payload, subtitleTag)
+	}
+
+	var out strings.Builder
+	emitted := 0
+	j := newSegmentJob(cues)
+	// emit writes out every block up to the first cue still missing.
+	emit := func() error {
+		var b strings.Builder
+		for ; emitted < len(file.blocks); emitted++ {
+			payload := ""
+			if cue := file.blocks[emitted].cue; cue >= 0 {
+				if !j.done[cue] {
+					break
+				}
+				payload = j.results[cue]
+			}
+			b.WriteString(file.render(emitted, payload))
+		}
+		if b.Len() == 0 {
+			return nil
+		}
+		out.WriteString(b.String())
+		if onChunk != nil {
+			return onChunk(b.String())
+		}
+		return nil
+	}
+	if onChunk != nil {
+		j.progress = emit
+	}
+
+	if err := t.translateSegments(ctx, j, opts); err != nil {
+		return "", err
+	}
+	if err := emit(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
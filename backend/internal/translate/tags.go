@@ -0,0 +1,87 @@
+package translate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+var (
+	// ErrInvalidLanguageTag is returned for strings that are not well-formed
+	// BCP-47 language tags.
+	ErrInvalidLanguageTag = errors.New("invalid language tag")
+	// ErrUnsupportedLanguage is returned for well-formed tags that are not
+	// in the language registry.
+	ErrUnsupportedLanguage = errors.New("unsupported language")
+)
+
+// NormalizeTag parses a BCP-47 tag such as "pt-br", "zh_Hant" or "es-419"
+// and returns its canonical form ("pt-BR", "zh-Hant", "es-419"). The result
+// must be in the language registry, so it is always safe to use in cache and
+// storage keys.
+func NormalizeTag(raw string) (string, error) {
+	raw = strings.ReplaceAll(strings.TrimSpace(raw), "_", "-")
+	if raw == "" {
+		return "", fmt.Errorf("%w: empty", ErrInvalidLanguageTag)
+	}
+
+	tag, err := language.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidLanguageTag, raw)
+	}
+	if len(tag.Variants()) > 0 || len(tag.Extensions()) > 0 {
+		return "", fmt.Errorf("%w: %q has variants or extensions", ErrInvalidLanguageTag, raw)
+	}
+
+	base, script, region := tag.Raw()
+	b, sc, r := base.String(), "", ""
+	if script.String() != "Zzzz" {
+		sc = script.String()
+	}
+	if region.String() != "ZZ" {
+		r = region.String()
+	}
+
+	// The registry only lists the regions and scripts that change a
+	// translation, so other subtags fall away: "zh-Hans-CN" becomes
+	// "zh-Hans", "de-DE" becomes "de". A script is only dropped when it is
+	// the language's usual one, so "pt-Latn-BR" becomes "pt-BR" but
+	// "ja-Latn" is not taken for Japanese.
+	candidates := []string{joinSubtags(b, sc, r)}
+	if r != "" {
+		candidates = append(candidates, joinSubtags(b, sc))
+	}
+	if likely, _ := language.Make(b).Script(); sc == "" || script == likely {
+		candidates = append(candidates, joinSubtags(b, r), b)
+	}
+
+	for _, normalized := range candidates {
+		if alias, ok := tagAliases[normalized]; ok {
+			normalized = alias
+		}
+		if IsKnownLanguage(normalized) {
+			return normalized, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedLanguage, raw)
+}
+
+// joinSubtags joins the non-empty subtags of a tag.
+func joinSubtags(subtags ...string) string {
+	var nonEmpty []string
+	for _, subtag := range subtags {
+		if subtag != "" {
+			nonEmpty = append(nonEmpty, subtag)
+		}
+	}
+	return strings.Join(nonEmpty, "-")
+}
+
+// BaseLanguage returns the language subtag of a normalized tag ("pt" for
+// "pt-BR").
+func BaseLanguage(tag string) string {
+	base, _, _ := strings.Cut(tag, "-")
+	return base
+}
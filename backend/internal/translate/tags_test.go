@@ -0,0 +1,89 @@
+package translate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr error
+	}{
+		{raw: "en", want: "en"},
+		{raw: "EN", want: "en"},
+		{raw: "en-US", want: "en-US"},
+		{raw: "en-us", want: "en-US"},
+		{raw: "en-AU", want: "en"},
+		{raw: "de-DE", want: "de"},
+		{raw: "de-AT", want: "de"},
+		{raw: "fr-FR", want: "fr"},
+		{raw: "fr-CA", want: "fr-CA"},
+		{raw: "ja-JP", want: "ja"},
+		{raw: "it-IT", want: "it"},
+		{raw: "pt-BR", want: "pt-BR"},
+		{raw: "pt_br", want: "pt-BR"},
+		{raw: "pt-AO", want: "pt"},
+		{raw: "es-MX", want: "es-MX"},
+		{raw: "es-419", want: "es-419"},
+		{raw: "es-AR", want: "es"},
+		{raw: "zh-CN", want: "zh-Hans"},
+		{raw: "zh-TW", want: "zh-Hant"},
+		{raw: "zh_Hant", want: "zh-Hant"},
+		{raw: "zh-Hans-CN", want: "zh-Hans"},
+		{raw: "zh-Hant-TW", want: "zh-Hant"},
+		{raw: "pt-Latn-BR", want: "pt-BR"},
+		{raw: "en-Latn", want: "en"},
+		{raw: "ja-Jpan-JP", want: "ja"},
+		{raw: " de-DE ", want: "de"},
+		{raw: "ja-Latn", wantErr: ErrUnsupportedLanguage},
+		{raw: "sr-Latn-RS", wantErr: ErrUnsupportedLanguage},
+		{raw: "sw", wantErr: ErrUnsupportedLanguage},
+		{raw: "", wantErr: ErrInvalidLanguageTag},
+		{raw: "not a tag", wantErr: ErrInvalidLanguageTag},
+		{raw: "de-DE-1996", wantErr: ErrInvalidLanguageTag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := NormalizeTag(tt.raw)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("NormalizeTag(%q) = %q, %v; want error %v", tt.raw, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("NormalizeTag(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLanguageCode(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{raw: "en", want: "en", ok: true},
+		{raw: "EN.", want: "en", ok: true},
+		{raw: "'fr'", want: "fr", ok: true},
+		{raw: "de-DE", want: "de", ok: true},
+		{raw: " pt-BR\n", want: "pt-BR", ok: true},
+		{raw: "zh-TW", want: "zh-Hant", ok: true},
+		{raw: "es-419", want: "es-419", ok: true},
+		{raw: "Japanese", want: "ja", ok: true},
+		{raw: "unknown", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, ok := NormalizeLanguageCode(tt.raw)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("NormalizeLanguageCode(%q) = %q, %t; want %q, %t", tt.raw, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package translate
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/lingopaste/backend/internal/models"
+)
+
+// defaultTemperature is used for translations unless a custom tone sets its own.
+const defaultTemperature float32 = 0.3
+
+// builtinTones are available to everyone; accounts may add custom tones
+// under any other name.
+var builtinTones = []string{"default", "professional", "friendly", "brusque"}
+
+var toneNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,39}$`)
+
+// BuiltinTones returns the names of the tones every paste may use.
+func BuiltinTones() []string {
+	tones := make([]string, len(builtinTones))
+	copy(tones, builtinTones)
+	return tones
+}
+
+func IsBuiltinTone(name string) bool {
+	for _, tone := range builtinTones {
+		if tone == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidToneName reports whether name can be used for a custom tone:
+// lowercase letters, digits and dashes, not shadowing a built-in tone.
+func IsValidToneName(name string) bool {
+	return toneNamePattern.MatchString(name) && !IsBuiltinTone(name)
+}
+
+func getToneInstruction(tone string) string {
+	switch tone {
+	case "professional":
+		return "Use formal business language. Be polite, professional, and respectful."
+	case "friendly":
+		return "Use warm and conversational language. Be approachable and personable."
+	case "brusque":
+		return "Be direct and concise. Get straight to the point without unnecessary words."
+	default:
+		return "Use natural and accurate language. Be clear and appropriate for general use."
+	}
+}
+
+// toneInstruction returns the prompt text for the tone in opts, including
+// example phrases when a custom tone provides them.
+func toneInstruction(opts Options) string {
+	if opts.CustomTone == nil {
+		return getToneInstruction(opts.Tone)
+	}
+
+	instruction := opts.CustomTone.Instruction
+	if len(opts.CustomTone.Examples) > 0 {
+		var b strings.Builder
+		b.WriteString(instruction)
+		b.WriteString("\nExample phrases in this tone:")
+		for _, example := range opts.CustomTone.Examples {
+			b.WriteString("\n- " + quoteTerm(example))
+		}
+		instruction = b.String()
+	}
+	return instruction
+}
+
+// temperature returns the sampling temperature for opts. Romanization has
+// one right answer, so it is not sampled.
+func temperature(opts Options) float32 {
+	if opts.Romanize {
+		return 0
+	}
+	if opts.CustomTone != nil && opts.CustomTone.Temperature != nil {
+		return *opts.CustomTone.Temperature
+	}
+	return defaultTemperature
+}
+
+// FindCustomTone returns the custom tone called name, or nil.
+func FindCustomTone(tones []models.CustomTone, name string) *models.CustomTone {
+	for i := range tones {
+		if tones[i].Name == name {
+			return &tones[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package translate
+
+import (
+	"context"
+
+	"github.com/lingopaste/backend/internal/models"
+)
+
+// Translator is implemented by every translation backend. Handlers depend on
+// this interface rather than a concrete provider so backends can be swapped
+// through configuration.
+type Translator interface {
+	// DetectLanguage returns the BCP-47 tag of the language text is written in.
+	DetectLanguage(ctx context.Context, text string) (string, error)
+	// Translate renders text according to opts.
+	Translate(ctx context.Context, text string, opts Options) (string, error)
+}
+
+// Options describes how a single text should be translated.
+type Options struct {
+	// SourceLanguage is the detected language of the text, if known. It
+	// only informs routing.
+	SourceLanguage string
+	TargetLanguage string
+	Tone           string
+	// Formality is one of the levels the target language supports, or
+	// empty for no preference.
+	Formality string
+	// CustomTone carries the definition of Tone when it is an account's
+	// custom tone rather than a built-in one.
+	CustomTone *models.CustomTone
+	// TranslateCodeComments translates comments inside fenced code blocks
+	// instead of leaving the blocks completely untouched.
+	TranslateCodeComments bool
+	// Glossary lists the terms whose rendering is fixed for this translation.
+	Glossary []GlossaryTerm
+	// Format is the structured format of the text, such as FormatSRT, or
+	// empty for plain text.
+	Format string
+	// PluralForm describes the counts a plural form of a localization
+	// string is used for, when the text consists of such forms.
+	PluralForm string
+	// Romanize renders the text, already in TargetLanguage, in Latin script
+	// instead of translating it.
+	Romanize bool
+	// GlossaryVersion identifies the glossary Glossary was drawn from, so
+	// that translation memory keeps renderings under different glossaries
+	// apart.
+	GlossaryVersion string
+}
+
+// StreamingTranslator is implemented by backends that can deliver a
+// translation incrementally. onChunk is called with each fragment as it
+// arrives; the returned string is the complete translation.
+type StreamingTranslator interface {
+	TranslateStream(ctx context.Context, text string, opts Options, onChunk func(string) error) (string, error)
+}
@@ -0,0 +1,111 @@
+package translate
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/lingopaste/backend/internal/models"
+)
+
+// Prices in USD per million tokens, matched by longest model name prefix so
+// dated snapshots ("gpt-4o-mini-2024-07-18") resolve to their family.
+// Unlisted models, self-hosted ones included, are costed at zero.
+var modelPrices = map[string]struct{ prompt, completion float64 }{
+	"gpt-4o-mini":   {0.15, 0.60},
+	"gpt-4o":        {2.50, 10.00},
+	"gpt-4.1-nano":  {0.10, 0.40},
+	"gpt-4.1-mini":  {0.40, 1.60},
+	"gpt-4.1":       {2.00, 8.00},
+	"gpt-4-turbo":   {10.00, 30.00},
+	"gpt-3.5-turbo": {0.50, 1.50},
+}
+
+// EstimateCost returns the list price in USD of a call to model.
+func EstimateCost(model string, promptTokens, completionTokens int) float64 {
+	best := ""
+	for name := range modelPrices {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return 0
+	}
+	price := modelPrices[best]
+	return (float64(promptTokens)*price.prompt + float64(completionTokens)*price.completion) / 1e6
+}
+
+type meterKey struct{}
+
+// Meter accumulates the usage of every provider call made with a context
+// returned by WithMeter, including calls from concurrent segments and
+// failed attempts, along with the backends that produced the result.
+type Meter struct {
+	mu    sync.Mutex
+	usage models.Usage
+}
+
+func WithMeter(ctx context.Context) (context.Context, *Meter) {
+	m := &Meter{}
+	return context.WithValue(ctx, meterKey{}, m), m
+}
+
+func (m *Meter) Usage() models.Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage
+}
+
+func (m *Meter) add(model string, promptTokens, completionTokens int, estimated bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.usage.Model = appendName(m.usage.Model, model)
+	m.usage.Calls++
+	m.usage.PromptTokens += promptTokens
+	m.usage.CompletionTokens += completionTokens
+	m.usage.CostUSD += EstimateCost(model, promptTokens, completionTokens)
+	m.usage.Estimated = m.usage.Estimated || estimated
+}
+
+// appendName adds name to a comma-separated list unless already present.
+func appendName(list, name string) string {
+	if list == "" {
+		return name
+	}
+	for _, existing := range strings.Split(list, ",") {
+		if existing == name {
+			return list
+		}
+	}
+	return list + "," + name
+}
+
+// recordBackend notes on the context's meter, if any, that backend produced
+// (part of) the result.
+func recordBackend(ctx context.Context, backend string) {
+	if m, ok := ctx.Value(meterKey{}).(*Meter); ok {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.usage.Backend = appendName(m.usage.Backend, backend)
+	}
+}
+
+// recordMemory adds translation memory lookups to the context's meter, if
+// any.
+func recordMemory(ctx context.Context, hits, misses int) {
+	if m, ok := ctx.Value(meterKey{}).(*Meter); ok {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.usage.MemoryHits += hits
+		m.usage.MemoryMisses += misses
+	}
+}
+
+// recordUsage adds a provider call to the context's meter, if any.
+func recordUsage(ctx context.Context, model string, promptTokens, completionTokens int, estimated bool) {
+	if m, ok := ctx.Value(meterKey{}).(*Meter); ok {
+		m.add(model, promptTokens, completionTokens, estimated)
+	}
+}
@@ -54,7 +54,10 @@ POLICY_DOCUMENT=$(cat <<EOF
         "arn:aws:dynamodb:${AWS_REGION}:${ACCOUNT_ID}:table/lingopaste-accounts/index/*",
         "arn:aws:dynamodb:${AWS_REGION}:${ACCOUNT_ID}:table/lingopaste-pastes",
         "arn:aws:dynamodb:${AWS_REGION}:${ACCOUNT_ID}:table/lingopaste-pastes/index/*",
-        "arn:aws:dynamodb:${AWS_REGION}:${ACCOUNT_ID}:table/lingopaste-rate-limits"
+        "arn:aws:dynamodb:${AWS_REGION}:${ACCOUNT_ID}:table/lingopaste-rate-limits",
+        "arn:aws:dynamodb:${AWS_REGION}:${ACCOUNT_ID}:table/lingopaste-usage",
+        "arn:aws:dynamodb:${AWS_REGION}:${ACCOUNT_ID}:table/lingopaste-usage/index/*",
+        "arn:aws:dynamodb:${AWS_REGION}:${ACCOUNT_ID}:table/lingopaste-translation-memory"
       ]
     },
     {
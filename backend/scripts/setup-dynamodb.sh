@@ -89,6 +89,55 @@ else
     --region $AWS_REGION
 fi
 
+# Create Usage table
+if table_exists lingopaste-usage; then
+    echo "Usage table already exists, skipping..."
+else
+    echo "Creating usage table..."
+    aws dynamodb create-table \
+    --table-name lingopaste-usage \
+    --attribute-definitions \
+        AttributeName=identifier,AttributeType=S \
+        AttributeName=date,AttributeType=S \
+    --key-schema \
+        AttributeName=identifier,KeyType=HASH \
+        AttributeName=date,KeyType=RANGE \
+    --global-secondary-indexes \
+        "[
+            {
+                \"IndexName\": \"date-index\",
+                \"KeySchema\": [{\"AttributeName\":\"date\",\"KeyType\":\"HASH\"}],
+                \"Projection\":{\"ProjectionType\":\"ALL\"},
+                \"ProvisionedThroughput\":{\"ReadCapacityUnits\":5,\"WriteCapacityUnits\":5}
+            }
+        ]" \
+    --provisioned-throughput \
+        ReadCapacityUnits=5,WriteCapacityUnits=5 \
+    --region $AWS_REGION
+fi
+
+# Create Translation Memory table
+if table_exists lingopaste-translation-memory; then
+    echo "Translation memory table already exists, skipping..."
+else
+    echo "Creating translation memory table..."
+    aws dynamodb create-table \
+    --table-name lingopaste-translation-memory \
+    --attribute-definitions \
+        AttributeName=segment_key,AttributeType=S \
+    --key-schema \
+        AttributeName=segment_key,KeyType=HASH \
+    --provisioned-throughput \
+        ReadCapacityUnits=5,WriteCapacityUnits=5 \
+    --region $AWS_REGION
+    aws dynamodb wait table-exists --table-name lingopaste-translation-memory --region $AWS_REGION
+    aws dynamodb update-time-to-live \
+        --table-name lingopaste-translation-memory \
+        --time-to-live-specification \
+            "Enabled=true,AttributeName=ttl" \
+        --region $AWS_REGION
+fi
+
 # Enable TTL on rate limits table (if not already enabled)
 if table_exists lingopaste-rate-limits; then
     echo "Checking TTL status on rate limits table..."
@@ -116,3 +165,5 @@ echo "Table names:"
 echo "  - lingopaste-accounts"
 echo "  - lingopaste-pastes"
 echo "  - lingopaste-rate-limits"
+echo "  - lingopaste-usage"
+echo "  - lingopaste-translation-memory"
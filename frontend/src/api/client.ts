@@ -3,30 +3,109 @@ const API_BASE_URL = import.meta.env.VITE_API_URL || '/api';
 export interface CreatePasteRequest {
   content: string;
   tone: string;
+  formality?: Formality;
+  target_languages?: string[];
 }
 
 export interface CreatePasteResponse {
   paste_id: string;
   original_language: string;
   available_languages: string[];
+  pending_languages?: string[];
 }
 
 export interface GetPasteResponse {
   paste_id: string;
   original_language: string;
   tone: string;
+  formality?: Formality;
+  format?: PasteFormat;
   created_at: string;
   original: string;
   translations: { [key: string]: string };
   available_translations: string[];
+  variants: TranslationVariant[];
+  translation_status: { [language: string]: TranslationStatus };
+  romanizations?: { [key: string]: string };
+}
+
+export type TranslationStatus = 'pending' | 'ready' | 'failed';
+
+export type Formality = 'informal' | 'formal' | 'honorific';
+
+export type PasteFormat = 'srt' | 'vtt' | 'json' | 'yaml' | 'po' | 'html';
+
+export interface TranslationVariant {
+  key: string;
+  language: string;
+  tone: string;
+  formality?: Formality;
 }
 
 export interface TranslateResponse {
   language: string;
+  tone: string;
+  formality?: Formality;
   translation: string;
+  romanized?: string;
+}
+
+export interface TranslateError {
+  error: string;
+  retry_after?: number;
+}
+
+export interface BatchTranslateResponse {
+  results: Record<string, TranslateResponse>;
+  errors?: Record<string, TranslateError>;
+}
+
+export interface TextSpan {
+  start: number;
+  end: number;
+}
+
+export interface AlignedPair {
+  source: TextSpan;
+  target: TextSpan;
+}
+
+export interface AlignmentResponse {
+  language: string;
+  tone: string;
+  formality?: Formality;
+  original: string;
+  translation: string;
+  pairs: AlignedPair[];
+}
+
+export interface Language {
+  code: string;
+  name: string;
+  native_name: string;
+  direction: 'ltr' | 'rtl';
+  supports_tone: boolean;
+  formality_levels?: Formality[];
+  romanization?: string;
+}
+
+export interface ListLanguagesResponse {
+  languages: Language[];
 }
 
 class APIClient {
+  async getLanguages(): Promise<Language[]> {
+    const response = await fetch(`${API_BASE_URL}/languages`);
+
+    if (!response.ok) {
+      const error = await response.text();
+      throw new Error(error || 'Failed to load languages');
+    }
+
+    const data: ListLanguagesResponse = await response.json();
+    return data.languages;
+  }
+
   async createPaste(request: CreatePasteRequest): Promise<CreatePasteResponse> {
     const response = await fetch(`${API_BASE_URL}/pastes`, {
       method: 'POST',
@@ -44,8 +123,9 @@ class APIClient {
     return response.json();
   }
 
-  async getPaste(pasteId: string): Promise<GetPasteResponse> {
-    const response = await fetch(`${API_BASE_URL}/pastes/${pasteId}`);
+  async getPaste(pasteId: string, romanized = false): Promise<GetPasteResponse> {
+    const query = romanized ? '?romanized=true' : '';
+    const response = await fetch(`${API_BASE_URL}/pastes/${pasteId}${query}`);
 
     if (!response.ok) {
       const error = await response.text();
@@ -55,8 +135,68 @@ class APIClient {
     return response.json();
   }
 
-  async translate(pasteId: string, language: string): Promise<TranslateResponse> {
-    const response = await fetch(`${API_BASE_URL}/pastes/${pasteId}/translate?lang=${language}`);
+  async translate(pasteId: string, language: string, tone?: string, formality?: Formality, romanized = false): Promise<TranslateResponse> {
+    const params = new URLSearchParams({ lang: language });
+    if (romanized) {
+      params.set('romanized', 'true');
+    }
+    if (tone) {
+      params.set('tone', tone);
+    }
+    if (formality) {
+      params.set('formality', formality);
+    }
+    const response = await fetch(`${API_BASE_URL}/pastes/${pasteId}/translate?${params}`);
+
+    if (!response.ok) {
+      const error = await response.text();
+      throw new Error(error || 'Failed to translate');
+    }
+
+    return response.json();
+  }
+
+  async align(pasteId: string, language: string, tone?: string, formality?: Formality): Promise<AlignmentResponse> {
+    const params = new URLSearchParams({ lang: language });
+    if (tone) {
+      params.set('tone', tone);
+    }
+    if (formality) {
+      params.set('formality', formality);
+    }
+    const response = await fetch(`${API_BASE_URL}/pastes/${pasteId}/align?${params}`);
+
+    if (!response.ok) {
+      const error = await response.text();
+      throw new Error(error || 'Failed to align translation');
+    }
+
+    return response.json();
+  }
+
+  rawUrl(pasteId: string, language?: string, tone?: string, formality?: Formality): string {
+    const params = new URLSearchParams();
+    if (language) {
+      params.set('lang', language);
+    }
+    if (tone) {
+      params.set('tone', tone);
+    }
+    if (formality) {
+      params.set('formality', formality);
+    }
+    const query = params.toString() ? `?${params}` : '';
+    return `${API_BASE_URL}/pastes/${pasteId}/raw${query}`;
+  }
+
+  async translateMany(pasteId: string, languages: string[], tone?: string, formality?: Formality): Promise<BatchTranslateResponse> {
+    const response = await fetch(`${API_BASE_URL}/pastes/${pasteId}/translate`, {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+      },
+      body: JSON.stringify({ languages, tone, formality }),
+    });
 
     if (!response.ok) {
       const error = await response.text();
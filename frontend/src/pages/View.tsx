@@ -1,6 +1,6 @@
 import { useState, useEffect } from 'react'
 import { useParams } from 'react-router-dom'
-import { apiClient, GetPasteResponse } from '../api/client'
+import { apiClient, GetPasteResponse, Language } from '../api/client'
 import './View.css'
 
 function View() {
@@ -11,6 +11,13 @@ function View() {
   const [viewMode, setViewMode] = useState<'translation' | 'original' | 'side-by-side'>('translation')
   const [selectedLanguage, setSelectedLanguage] = useState('')
   const [translating, setTranslating] = useState(false)
+  const [languages, setLanguages] = useState<Language[]>([])
+
+  useEffect(() => {
+    apiClient.getLanguages()
+      .then(setLanguages)
+      .catch(() => setLanguages([]))
+  }, [])
 
   useEffect(() => {
     const fetchPaste = async () => {
@@ -94,27 +101,11 @@ function View() {
               onChange={(e) => handleLanguageChange(e.target.value)}
               disabled={translating}
             >
-              <option value="en">English</option>
-              <option value="es">Español</option>
-              <option value="fr">Français</option>
-              <option value="de">Deutsch</option>
-              <option value="ja">日本語</option>
-              <option value="zh">中文</option>
-              <option value="pt">Português</option>
-              <option value="ru">Русский</option>
-              <option value="ko">한국어</option>
-              <option value="it">Italiano</option>
-              <option value="ar">العربية</option>
-              <option value="hi">हिन्दी</option>
-              <option value="nl">Nederlands</option>
-              <option value="pl">Polski</option>
-              <option value="tr">Türkçe</option>
-              <option value="vi">Tiếng Việt</option>
-              <option value="th">ไทย</option>
-              <option value="sv">Svenska</option>
-              <option value="da">Dansk</option>
-              <option value="fi">Suomi</option>
-              <option value="no">Norsk</option>
+              {languages.map((lang) => (
+                <option key={lang.code} value={lang.code}>
+                  {lang.native_name}
+                </option>
+              ))}
             </select>
             {translating && <span className="translating">Translating...</span>}
           </div>